@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +14,26 @@ type Config struct {
 	Port         string
 	GinMode      string
 	PostgresURL  string
+	AutoMigrate  bool
+
+	StockfishPath             string
+	EnginePoolSize            int
+	EngineAcquireTimeout      time.Duration
+	EngineHealthCheckInterval time.Duration
+	BotLevelsFile             string
+
+	LLMDefaultModel     string
+	LLMChatModel        string
+	LLMMoveParsingModel string
+	LLMHintModel        string
+	LLMVisionModel      string
+	LLMTimeout          time.Duration
+	LLMMaxRetries       int
+
+	VoiceDialogueTTL         time.Duration
+	VoiceConfidenceThreshold int
+
+	IdempotencyKeyTTL time.Duration
 }
 
 func LoadConfig() *Config {
@@ -24,6 +46,26 @@ func LoadConfig() *Config {
 		Port:         getEnvOrDefault("PORT", "8080"),
 		GinMode:      getEnvOrDefault("GIN_MODE", "release"),
 		PostgresURL:  os.Getenv("POSTGRES_URL"),
+		AutoMigrate:  getEnvBoolOrDefault("AUTO_MIGRATE", false),
+
+		StockfishPath:             getEnvOrDefault("STOCKFISH_PATH", "stockfish"),
+		EnginePoolSize:            getEnvIntOrDefault("ENGINE_POOL_SIZE", 2),
+		EngineAcquireTimeout:      getEnvDurationOrDefault("ENGINE_ACQUIRE_TIMEOUT", 10*time.Second),
+		EngineHealthCheckInterval: getEnvDurationOrDefault("ENGINE_HEALTH_CHECK_INTERVAL", 30*time.Second),
+		BotLevelsFile:             os.Getenv("BOT_LEVELS_FILE"),
+
+		LLMDefaultModel:     getEnvOrDefault("LLM_DEFAULT_MODEL", "gemini-2.5-pro"),
+		LLMChatModel:        getEnvOrDefault("LLM_CHAT_MODEL", "gemini-2.0-flash"),
+		LLMMoveParsingModel: getEnvOrDefault("LLM_MOVE_PARSING_MODEL", "gemini-2.0-flash"),
+		LLMHintModel:        getEnvOrDefault("LLM_HINT_MODEL", "gemini-2.5-pro"),
+		LLMVisionModel:      getEnvOrDefault("LLM_VISION_MODEL", "gemini-2.5-pro"),
+		LLMTimeout:          getEnvDurationOrDefault("LLM_TIMEOUT", 30*time.Second),
+		LLMMaxRetries:       getEnvIntOrDefault("LLM_MAX_RETRIES", 2),
+
+		VoiceDialogueTTL:         getEnvDurationOrDefault("VOICE_DIALOGUE_TTL", 2*time.Minute),
+		VoiceConfidenceThreshold: getEnvIntOrDefault("VOICE_CONFIDENCE_THRESHOLD", 7),
+
+		IdempotencyKeyTTL: getEnvDurationOrDefault("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
 	}
 
 	return config
@@ -36,6 +78,30 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func (c *Config) IsValid() bool {
 	return c.GeminiAPIKey != ""
 }
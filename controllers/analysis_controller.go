@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -79,3 +81,36 @@ func (ac *AnalysisController) GetFenFromPicture(c *gin.Context) {
 
 	c.JSON(200, gin.H{"data": fen})
 }
+
+func (ac *AnalysisController) GetGamePGN(c *gin.Context) {
+	gameID := c.Param("game_id")
+
+	pgn, err := ac.Service.ExportPGN(gameID)
+	if errors.Is(err, services.ErrGameNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pgn"`, gameID))
+	c.Data(200, "application/x-chess-pgn", []byte(pgn))
+}
+
+func (ac *AnalysisController) GetGameReport(c *gin.Context) {
+	gameID := c.Param("game_id")
+
+	report, err := ac.Service.GetGameReport(gameID)
+	if errors.Is(err, services.ErrGameNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": report})
+}
@@ -1,13 +1,11 @@
 package controllers
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"samsungvoicebe/config"
+	"samsungvoicebe/llm"
 	"samsungvoicebe/models"
 	"strings"
 
@@ -16,11 +14,13 @@ import (
 
 type ChatController struct {
 	config *config.Config
+	llm    llm.LLM
 }
 
-func NewChatController(cfg *config.Config) *ChatController {
+func NewChatController(cfg *config.Config, llmClient llm.LLM) *ChatController {
 	return &ChatController{
 		config: cfg,
+		llm:    llmClient,
 	}
 }
 
@@ -213,7 +213,7 @@ Rules:
 Respond with ONLY the screen name (play/scan/lesson/analyze/setting), no additional text.
 `, req.Message)
 
-	response, err := cc.callGeminiAPI(enhancedPrompt)
+	response, err := cc.llm.Generate(c.Request.Context(), llm.TaskChat, enhancedPrompt)
 	if err != nil {
 		log.Printf("Gemini API error, falling back to keyword matching: %v", err)
 		// Fallback to keyword matching
@@ -262,61 +262,3 @@ Respond with ONLY the screen name (play/scan/lesson/analyze/setting), no additio
 		Screen:   screen,
 	})
 }
-
-func (cc *ChatController) callGeminiAPI(message string) (string, error) {
-	geminiReq := models.GeminiRequest{
-		Contents: []models.Content{
-			{
-				Parts: []models.Part{
-					{Text: message},
-				},
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(geminiReq)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %v", err)
-	}
-
-	url := "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent"
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-goog-api-key", cc.config.GeminiAPIKey)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to call Gemini API: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("gemini API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var geminiResp models.GeminiResponse
-	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %v", err)
-	}
-
-	if geminiResp.Error != nil {
-		return "", fmt.Errorf("gemini API error: %s", geminiResp.Error.Message)
-	}
-
-	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no response generated by Gemini")
-	}
-
-	return geminiResp.Candidates[0].Content.Parts[0].Text, nil
-}
@@ -1,14 +1,20 @@
 package controllers
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"math/rand"
 	"net/http"
 	"samsungvoicebe/config"
+	"samsungvoicebe/helper"
+	"samsungvoicebe/llm"
 	"samsungvoicebe/models"
+	"samsungvoicebe/narration"
+	"samsungvoicebe/pending"
+	"samsungvoicebe/search"
+	"samsungvoicebe/style"
+	"samsungvoicebe/tactics"
+	"samsungvoicebe/voice"
 	"strings"
 	"time"
 
@@ -16,14 +22,24 @@ import (
 	"github.com/notnil/chess"
 )
 
+// aiSearchTimeout bounds the built-in search; when it runs out the deepest
+// completed iteration is played.
+const aiSearchTimeout = 10 * time.Second
+
 type ChessController struct {
-	config *config.Config
+	config       *config.Config
+	llm          llm.LLM
+	voiceChoices *pending.Store[models.PendingChoice]
+	pendingMoves *pending.Store[models.PendingMove]
 }
 
-func NewChessController(cfg *config.Config) *ChessController {
+func NewChessController(cfg *config.Config, llmClient llm.LLM, voiceChoices *pending.Store[models.PendingChoice]) *ChessController {
 	rand.Seed(time.Now().UnixNano())
 	return &ChessController{
-		config: cfg,
+		config:       cfg,
+		llm:          llmClient,
+		voiceChoices: voiceChoices,
+		pendingMoves: pending.NewStore[models.PendingMove](cfg.VoiceDialogueTTL),
 	}
 }
 
@@ -57,14 +73,14 @@ func (cc *ChessController) PlayChess(c *gin.Context) {
 	}
 
 	if strings.TrimSpace(req.Message) == "" {
-		cc.handleAIMove(c, game, req.Mode)
+		cc.handleAIMove(c, game, req.Mode, req.Personality, req.NarrationOptions, req.IncludeWarnings)
 		return
 	}
 
 	cc.handlePlayerMove(c, req, game)
 }
 
-func (cc *ChessController) handleAIMove(c *gin.Context, game *chess.Game, mode string) {
+func (cc *ChessController) handleAIMove(c *gin.Context, game *chess.Game, mode, personality string, narrationOptions models.NarrationOptions, includeWarnings bool) {
 	validMoves := game.ValidMoves()
 	if len(validMoves) == 0 {
 		c.JSON(http.StatusOK, models.ChessResponse{
@@ -76,9 +92,18 @@ func (cc *ChessController) handleAIMove(c *gin.Context, game *chess.Game, mode s
 	}
 
 	strategy := models.GetAIStrategy(mode)
-	bestMove := cc.selectBestMoveWithStrategy(validMoves, game, strategy)
+	strategy.Personality, _ = models.GetPersonality(personality)
+	bestMove, err := cc.selectBestMoveWithStrategy(c.Request.Context(), game, strategy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ChessResponse{
+			Error: "Failed to search AI move: " + err.Error(),
+		})
+		return
+	}
 
-	err := game.Move(bestMove)
+	spoken := narration.Move(game.Position(), bestMove, narrationOptions)
+
+	err = game.Move(bestMove)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ChessResponse{
 			Error: "Failed to apply AI move: " + err.Error(),
@@ -90,205 +115,265 @@ func (cc *ChessController) handleAIMove(c *gin.Context, game *chess.Game, mode s
 	status := cc.getGameStatus(game)
 	winner := cc.getWinner(game)
 
+	var warnings []models.Warning
+	if includeWarnings && game.Outcome() == chess.NoOutcome {
+		warnings = narration.Warnings(game.Position(), narrationOptions)
+	}
+
 	c.JSON(http.StatusOK, models.ChessResponse{
 		Move:      bestMove.String(),
 		NewFen:    newFen,
 		Status:    status,
 		Winner:    winner,
 		IsGameEnd: game.Outcome() != chess.NoOutcome,
+		Narration: spoken,
+		Warnings:  warnings,
 	})
 }
 
 func (cc *ChessController) handlePlayerMove(c *gin.Context, req models.ChessRequest, game *chess.Game) {
-	analysis, err := cc.analyzeMoveTWithGemini(req.Message, req.Fen)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ChessResponse{
-			Error: "Failed to analyze move: " + err.Error(),
-		})
-		return
-	}
+	narrationOptions := req.NarrationOptions
+	if narrationOptions.Language == "" {
+		narrationOptions.Language = voice.DetectLanguage(req.Message)
+	}
+
+	// Moves read by the rule-based parser are exact; only Gemini's readings
+	// carry a confidence that may need confirming.
+	var analysis *models.GeminiMoveAnalysis
+	candidates := voice.ParseMove(game.Position(), req.Message)
+	if len(candidates) == 0 {
+		var err error
+		analysis, err = cc.analyzeMoveTWithGemini(c.Request.Context(), req.Message, req.Fen)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ChessResponse{
+				Error: "Failed to analyze move: " + err.Error(),
+			})
+			return
+		}
 
-	if !analysis.IsValidRequest {
-		c.JSON(http.StatusBadRequest, models.ChessResponse{
-			Error: "Invalid move request: " + analysis.Explanation,
-		})
-		return
+		if !analysis.IsValidRequest {
+			c.JSON(http.StatusBadRequest, models.ChessResponse{
+				Error: "Invalid move request: " + analysis.Explanation,
+			})
+			return
+		}
+
+		candidates, err = cc.findMoveFromAnalysis(analysis, game)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ChessResponse{
+				Error: "Invalid move: " + err.Error(),
+			})
+			return
+		}
 	}
 
-	move, err := cc.findMoveFromAnalysis(analysis, game)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ChessResponse{
-			Error: "Invalid move: " + err.Error(),
-		})
+	if len(candidates) > 1 {
+		cc.askToDisambiguate(c, game.Position(), candidates, narrationOptions)
 		return
 	}
 
-	err = game.Move(move)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ChessResponse{
-			Error: "Illegal move: " + err.Error(),
-		})
+	if analysis != nil && analysis.Confidence < cc.config.VoiceConfidenceThreshold {
+		cc.askToConfirm(c, game.Position(), candidates[0], analysis.Confidence, narrationOptions)
 		return
 	}
 
-	newFen := game.FEN()
-	status := cc.getGameStatus(game)
-	winner := cc.getWinner(game)
-
-	c.JSON(http.StatusOK, models.ChessResponse{
-		Move:      move.String(),
-		NewFen:    newFen,
-		Status:    status,
-		Winner:    winner,
-		IsGameEnd: game.Outcome() != chess.NoOutcome,
-	})
+	cc.playPlayerMove(c, game, candidates[0], narrationOptions)
 }
 
-func (cc *ChessController) selectBestMoveWithStrategy(moves []*chess.Move, game *chess.Game, strategy models.AIStrategy) *chess.Move {
-	if len(moves) == 0 {
-		return nil
-	}
-
-	if strategy.RandomFactor > 0.3 && rand.Float64() < strategy.RandomFactor {
-		return moves[rand.Intn(len(moves))]
+// ConfirmMove plays or discards a move that was held back for confirmation
+// because Gemini was not confident enough about it.
+func (cc *ChessController) ConfirmMove(c *gin.Context) {
+	var req models.ConfirmationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ChessResponse{
+			Error: "Invalid request format: " + err.Error(),
+		})
+		return
 	}
 
-	type ScoredMove struct {
-		move  *chess.Move
-		score int
+	var confirmed bool
+	if req.Confirm != nil {
+		confirmed = *req.Confirm
+	} else {
+		var ok bool
+		confirmed, ok = voice.ParseConfirmation(req.Answer)
+		if !ok {
+			c.JSON(http.StatusBadRequest, models.ChessResponse{
+				Error: "Answer is neither a confirmation nor a rejection",
+			})
+			return
+		}
 	}
 
-	var scoredMoves []ScoredMove
-
-	for _, move := range moves {
-		score := cc.scoreMove(move, game, strategy)
-		scoredMoves = append(scoredMoves, ScoredMove{move: move, score: score})
+	pendingMove, ok := cc.pendingMoves.Take(req.Token)
+	if !ok {
+		c.JSON(http.StatusGone, models.ChessResponse{
+			Error: "Confirmation token expired or unknown",
+		})
+		return
 	}
 
-	bestScore := scoredMoves[0].score
-	for _, sm := range scoredMoves {
-		if sm.score > bestScore {
-			bestScore = sm.score
-		}
+	if !confirmed {
+		c.JSON(http.StatusOK, models.ChessResponse{
+			NewFen: pendingMove.Fen,
+			Status: models.VoiceStatusRejected,
+		})
+		return
 	}
 
-	var bestMoves []*chess.Move
-	for _, sm := range scoredMoves {
-		if sm.score == bestScore {
-			bestMoves = append(bestMoves, sm.move)
-		}
+	fenNotation, err := chess.FEN(pendingMove.Fen)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ChessResponse{
+			Error: "Invalid FEN notation: " + err.Error(),
+		})
+		return
 	}
 
-	if len(bestMoves) > 1 && rand.Float64() < strategy.RandomFactor {
-		return bestMoves[rand.Intn(len(bestMoves))]
+	game := chess.NewGame(fenNotation)
+	move, err := helper.DecodeMove(game.Position(), pendingMove.Move)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ChessResponse{
+			Error: "Failed to restore pending move: " + err.Error(),
+		})
+		return
 	}
 
-	return bestMoves[0]
+	cc.playPlayerMove(c, game, move, pendingMove.Narration)
 }
 
-func (cc *ChessController) scoreMove(move *chess.Move, game *chess.Game, strategy models.AIStrategy) int {
-	score := 0
-
-	if strategy.PreferCaptures && move.HasTag(chess.Capture) {
-		score += 50
+func (cc *ChessController) askToConfirm(c *gin.Context, position *chess.Position, move *chess.Move, confidence int, narrationOptions models.NarrationOptions) {
+	token, expiresAt := cc.pendingMoves.Put(models.PendingMove{
+		Fen:       position.String(),
+		Move:      move.String(),
+		Narration: narrationOptions,
+	})
+	language := narrationOptions.Language
 
-		if strings.Contains(move.String(), "Q") {
-			score += 90
-		} else if strings.Contains(move.String(), "R") {
-			score += 50
-		} else if strings.Contains(move.String(), "B") || strings.Contains(move.String(), "N") {
-			score += 30
-		} else {
-			score += 10
-		}
-	}
+	c.JSON(http.StatusOK, models.ChessResponse{
+		NewFen: position.String(),
+		Status: models.VoiceStatusConfirmationRequired,
+		Confirmation: &models.Confirmation{
+			Token:      token,
+			Question:   voice.ConfirmQuestion(position, move, language),
+			Move:       move.String(),
+			San:        chess.AlgebraicNotation{}.Encode(position, move),
+			Spoken:     voice.DescribeCandidate(position, move, []*chess.Move{move}, language),
+			Confidence: confidence,
+			ExpiresAt:  expiresAt,
+		},
+	})
+}
 
-	if strategy.PreferCenter && cc.isCenterSquare(move.S2()) {
-		score += 20
+// Disambiguate completes an ambiguous move with the player's answer to the
+// question returned by PlayChess ("the one from b1", "yang dari b1").
+func (cc *ChessController) Disambiguate(c *gin.Context) {
+	var req models.DisambiguationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ChessResponse{
+			Error: "Invalid request format: " + err.Error(),
+		})
+		return
 	}
 
-	if move.HasTag(chess.Check) {
-		score += 30
+	choice, ok := cc.voiceChoices.Take(req.Token)
+	if !ok {
+		c.JSON(http.StatusGone, models.ChessResponse{
+			Error: "Disambiguation token expired or unknown",
+		})
+		return
 	}
 
-	outcome := game.Outcome()
-	if outcome == chess.WhiteWon || outcome == chess.BlackWon {
-		score += 1000
+	position, matches, err := voice.ResolveChoice(choice, req.Answer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ChessResponse{
+			Error: "Failed to resolve move: " + err.Error(),
+		})
+		return
 	}
 
-	if strategy.AvoidBlunders {
-		piece := game.Position().Board().Piece(move.S1())
-		if piece.Type() == chess.Queen && cc.isSquareUnderAttack(move.S2(), game) {
-			score -= 80
-		} else if piece.Type() == chess.Rook && cc.isSquareUnderAttack(move.S2(), game) {
-			score -= 40
-		}
+	if len(matches) > 1 {
+		cc.askToDisambiguate(c, position, matches, choice.Narration)
+		return
 	}
 
-	if strategy.RandomFactor > 0 {
-		randomBonus := int(float64(rand.Intn(20)) * strategy.RandomFactor)
-		score += randomBonus
+	fenNotation, err := chess.FEN(choice.Fen)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ChessResponse{
+			Error: "Invalid FEN notation: " + err.Error(),
+		})
+		return
 	}
 
-	if move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle) {
-		score += 25
-	}
+	cc.playPlayerMove(c, chess.NewGame(fenNotation), matches[0], choice.Narration)
+}
 
-	if move.Promo() != chess.NoPieceType {
-		score += 80
-	}
+func (cc *ChessController) askToDisambiguate(c *gin.Context, position *chess.Position, candidates []*chess.Move, narrationOptions models.NarrationOptions) {
+	token, expiresAt := cc.voiceChoices.Put(voice.NewChoice(position, candidates, narrationOptions))
+	language := narrationOptions.Language
 
-	return score
+	c.JSON(http.StatusOK, models.ChessResponse{
+		NewFen: position.String(),
+		Status: models.VoiceStatusDisambiguationRequired,
+		Disambiguation: &models.Disambiguation{
+			Token:      token,
+			Question:   voice.Question(position, candidates, language),
+			Candidates: voice.Candidates(position, candidates, language),
+			ExpiresAt:  expiresAt,
+		},
+	})
 }
 
-func (cc *ChessController) isCenterSquare(square chess.Square) bool {
-	centerSquares := []chess.Square{
-		chess.D4, chess.D5, chess.E4, chess.E5,
-	}
-
-	extendedCenter := []chess.Square{
-		chess.C3, chess.C4, chess.C5, chess.C6,
-		chess.D3, chess.D6,
-		chess.E3, chess.E6,
-		chess.F3, chess.F4, chess.F5, chess.F6,
-	}
+func (cc *ChessController) playPlayerMove(c *gin.Context, game *chess.Game, move *chess.Move, narrationOptions models.NarrationOptions) {
+	spoken := narration.Move(game.Position(), move, narrationOptions)
 
-	for _, center := range centerSquares {
-		if square == center {
-			return true
-		}
+	err := game.Move(move)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ChessResponse{
+			Error: "Illegal move: " + err.Error(),
+		})
+		return
 	}
 
-	for _, extended := range extendedCenter {
-		if square == extended {
-			return true
-		}
-	}
+	newFen := game.FEN()
+	status := cc.getGameStatus(game)
+	winner := cc.getWinner(game)
 
-	return false
+	c.JSON(http.StatusOK, models.ChessResponse{
+		Move:      move.String(),
+		NewFen:    newFen,
+		Status:    status,
+		Winner:    winner,
+		IsGameEnd: game.Outcome() != chess.NoOutcome,
+		Narration: spoken,
+	})
 }
 
-func (cc *ChessController) isSquareUnderAttack(square chess.Square, game *chess.Game) bool {
-	board := game.Position().Board()
-	turn := game.Position().Turn()
-
-	for sq := chess.A1; sq <= chess.H8; sq++ {
-		piece := board.Piece(sq)
+// selectBestMoveWithStrategy searches the position to strategy.Depth and
+// picks among the moves within the strategy's random margin of the best, or
+// by the strategy's personality when it has one.
+func (cc *ChessController) selectBestMoveWithStrategy(ctx context.Context, game *chess.Game, strategy models.AIStrategy) (*chess.Move, error) {
+	ctx, cancel := context.WithTimeout(ctx, aiSearchTimeout)
+	defer cancel()
 
-		if piece != chess.NoPiece && piece.Color() != turn {
-			moves := game.ValidMoves()
-			for _, move := range moves {
-				if move.S1() == sq && move.S2() == square {
-					return true
-				}
-			}
-		}
+	opts := search.Options{
+		Depth:        strategy.Depth,
+		RandomFactor: strategy.RandomFactor,
+	}
+	if strategy.Personality.Name != "" {
+		opts.Window = strategy.Personality.EvalWindow
+		opts.Bias = style.Bias(game.Position(), strategy.Personality)
 	}
 
-	return false
+	result, err := search.BestMove(ctx, game.Position(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Move, nil
 }
 
-func (cc *ChessController) findMoveFromAnalysis(analysis *models.GeminiMoveAnalysis, game *chess.Game) (*chess.Move, error) {
+// findMoveFromAnalysis returns the legal moves matching Gemini's reading of
+// the request; more than one means the request was ambiguous.
+func (cc *ChessController) findMoveFromAnalysis(analysis *models.GeminiMoveAnalysis, game *chess.Game) ([]*chess.Move, error) {
 	validMoves := game.ValidMoves()
 
 	if analysis.FromSquare != "" && analysis.ToSquare != "" {
@@ -297,7 +382,7 @@ func (cc *ChessController) findMoveFromAnalysis(analysis *models.GeminiMoveAnaly
 
 		for _, move := range validMoves {
 			if strings.ToLower(move.S1().String()) == fromSquare && strings.ToLower(move.S2().String()) == toSquare {
-				return move, nil
+				return []*chess.Move{move}, nil
 			}
 		}
 	}
@@ -307,7 +392,7 @@ func (cc *ChessController) findMoveFromAnalysis(analysis *models.GeminiMoveAnaly
 		for _, move := range validMoves {
 			moveStr := strings.ToLower(move.String())
 			if moveStr == notation || moveStr == strings.ReplaceAll(notation, "x", "") {
-				return move, nil
+				return []*chess.Move{move}, nil
 			}
 		}
 	}
@@ -321,18 +406,16 @@ func (cc *ChessController) findMoveFromAnalysis(analysis *models.GeminiMoveAnaly
 			}
 		}
 
-		if len(possibleMoves) == 1 {
-			return possibleMoves[0], nil
-		} else if len(possibleMoves) > 1 {
-			if analysis.FromSquare != "" {
-				fromSquare := strings.ToLower(analysis.FromSquare)
-				for _, move := range possibleMoves {
-					if strings.ToLower(move.S1().String()) == fromSquare {
-						return move, nil
-					}
+		if len(possibleMoves) > 1 && analysis.FromSquare != "" {
+			fromSquare := strings.ToLower(analysis.FromSquare)
+			for _, move := range possibleMoves {
+				if strings.ToLower(move.S1().String()) == fromSquare {
+					return []*chess.Move{move}, nil
 				}
 			}
-			return nil, fmt.Errorf("ambiguous move to %s, please specify which piece", analysis.ToSquare)
+		}
+		if len(possibleMoves) > 0 {
+			return possibleMoves, nil
 		}
 	}
 
@@ -357,23 +440,8 @@ func (cc *ChessController) getGameStatus(game *chess.Game) string {
 	case chess.Draw:
 		return "draw"
 	default:
-		validMoves := game.ValidMoves()
-		if len(validMoves) > 0 {
-			board := game.Position().Board()
-			kingSquare := chess.NoSquare
-			turn := game.Position().Turn()
-
-			for sq := chess.A1; sq <= chess.H8; sq++ {
-				piece := board.Piece(sq)
-				if piece.Type() == chess.King && piece.Color() == turn {
-					kingSquare = sq
-					break
-				}
-			}
-
-			if kingSquare != chess.NoSquare && cc.isSquareUnderAttack(kingSquare, game) {
-				return "check"
-			}
+		if tactics.InCheck(game.Position()) {
+			return "check"
 		}
 		return "ongoing"
 	}
@@ -392,7 +460,7 @@ func (cc *ChessController) getWinner(game *chess.Game) string {
 	}
 }
 
-func (cc *ChessController) analyzeMoveTWithGemini(message, fen string) (*models.GeminiMoveAnalysis, error) {
+func (cc *ChessController) analyzeMoveTWithGemini(ctx context.Context, message, fen string) (*models.GeminiMoveAnalysis, error) {
 	prompt := fmt.Sprintf(`
 Analyze this chess move request and extract the move information.
 
@@ -453,87 +521,13 @@ Respond with ONLY a JSON object in this exact format:
 }
 
 If the message is clearly not about making a chess move, set is_valid_request to false and explain why in explanation.
+confidence is an integer from 1 (a guess) to 10 (certain) saying how sure you are that the move matches what the player said.
 `, fen, message)
 
-	response, err := cc.callGeminiAPI(prompt)
-	if err != nil {
-		return nil, err
-	}
-
-	response = strings.TrimSpace(response)
-
-	if strings.HasPrefix(response, "```json") {
-		response = strings.TrimPrefix(response, "```json")
-		response = strings.TrimSuffix(response, "```")
-	} else if strings.HasPrefix(response, "```") {
-		response = strings.TrimPrefix(response, "```")
-		response = strings.TrimSuffix(response, "```")
-	}
-
-	response = strings.TrimSpace(response)
-
 	var analysis models.GeminiMoveAnalysis
-	if err := json.Unmarshal([]byte(response), &analysis); err != nil {
-		return nil, fmt.Errorf("failed to parse Gemini response: %v", err)
+	if err := cc.llm.GenerateJSON(ctx, llm.TaskMoveParsing, prompt, &analysis); err != nil {
+		return nil, fmt.Errorf("failed to analyze move with Gemini: %w", err)
 	}
 
 	return &analysis, nil
 }
-
-func (cc *ChessController) callGeminiAPI(message string) (string, error) {
-	geminiReq := models.GeminiRequest{
-		Contents: []models.Content{
-			{
-				Parts: []models.Part{
-					{Text: message},
-				},
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(geminiReq)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %v", err)
-	}
-
-	url := "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent"
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-goog-api-key", cc.config.GeminiAPIKey)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to call Gemini API: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("gemini API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var geminiResp models.GeminiResponse
-	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %v", err)
-	}
-
-	if geminiResp.Error != nil {
-		return "", fmt.Errorf("gemini API error: %s", geminiResp.Error.Message)
-	}
-
-	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no response generated by Gemini")
-	}
-
-	return geminiResp.Candidates[0].Content.Parts[0].Text, nil
-}
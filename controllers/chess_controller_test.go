@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"samsungvoicebe/config"
+	"samsungvoicebe/llm"
+	"samsungvoicebe/models"
+	"samsungvoicebe/pending"
+)
+
+const startFen = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+func newChessRouter(fake *llm.Fake) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{VoiceDialogueTTL: time.Minute, VoiceConfidenceThreshold: 7}
+	controller := NewChessController(cfg, fake, pending.NewStore[models.PendingChoice](time.Minute))
+
+	router := gin.New()
+	router.POST("/ai", controller.PlayChess)
+	router.POST("/ai/confirm", controller.ConfirmMove)
+	router.POST("/ai/disambiguate", controller.Disambiguate)
+	return router
+}
+
+func post(t *testing.T, router *gin.Engine, path string, body any) (int, models.ChessResponse) {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data)))
+
+	var response models.ChessResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding %s response %q: %v", path, recorder.Body.String(), err)
+	}
+	return recorder.Code, response
+}
+
+func moveRequest(message string) models.ChessRequest {
+	return models.ChessRequest{Message: message, Fen: startFen, Type: "white", Mode: "easy"}
+}
+
+func TestPlayChessParsesMovesWithoutLLM(t *testing.T) {
+	fake := llm.NewFake()
+	router := newChessRouter(fake)
+
+	code, response := post(t, router, "/ai", moveRequest("knight to f3"))
+
+	if code != http.StatusOK || response.Move != "g1f3" {
+		t.Fatalf("PlayChess = %d %+v, want 200 with g1f3", code, response)
+	}
+	if len(fake.Calls) != 0 {
+		t.Errorf("the LLM was called %d times for a move the parser reads", len(fake.Calls))
+	}
+}
+
+func TestPlayChessFallsBackToLLM(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		err      error
+		wantCode int
+		wantMove string
+		wantAsk  bool
+	}{
+		{
+			name:     "confident reading",
+			response: `{"is_valid_request": true, "from_square": "e2", "to_square": "e4", "move_notation": "e2e4", "confidence": 9}`,
+			wantCode: http.StatusOK,
+			wantMove: "e2e4",
+		},
+		{
+			name:     "reading in a code fence",
+			response: "```json\n{\"is_valid_request\": true, \"to_square\": \"e4\", \"confidence\": 10}\n```",
+			wantCode: http.StatusOK,
+			wantMove: "e2e4",
+		},
+		{
+			name:     "unsure reading is asked back",
+			response: `{"is_valid_request": true, "from_square": "d2", "to_square": "d4", "confidence": 4}`,
+			wantCode: http.StatusOK,
+			wantAsk:  true,
+		},
+		{
+			name:     "not a move",
+			response: `{"is_valid_request": false, "explanation": "greeting"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "illegal move",
+			response: `{"is_valid_request": true, "from_square": "e2", "to_square": "e5", "confidence": 9}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "llm failure",
+			err:      errors.New("quota exceeded"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := llm.NewFake()
+			fake.Queue(llm.TaskMoveParsing, tt.response)
+			fake.Err = tt.err
+			router := newChessRouter(fake)
+
+			code, response := post(t, router, "/ai", moveRequest("do the usual opening thing"))
+
+			if code != tt.wantCode {
+				t.Fatalf("PlayChess code = %d, want %d (%+v)", code, tt.wantCode, response)
+			}
+			if response.Move != tt.wantMove {
+				t.Errorf("PlayChess move = %q, want %q", response.Move, tt.wantMove)
+			}
+			if asked := response.Confirmation != nil; asked != tt.wantAsk {
+				t.Errorf("PlayChess asked for confirmation = %v, want %v", asked, tt.wantAsk)
+			}
+			if len(fake.Calls) != 1 || fake.Calls[0].Task != llm.TaskMoveParsing {
+				t.Errorf("LLM calls = %+v, want one move parsing call", fake.Calls)
+			}
+		})
+	}
+}
+
+func TestConfirmMove(t *testing.T) {
+	confirm := true
+	reject := false
+	tests := []struct {
+		name       string
+		request    models.ConfirmationRequest
+		wantStatus string
+		wantMove   string
+	}{
+		{name: "confirmed", request: models.ConfirmationRequest{Confirm: &confirm}, wantMove: "d2d4"},
+		{name: "rejected", request: models.ConfirmationRequest{Confirm: &reject}, wantStatus: models.VoiceStatusRejected},
+		{name: "spoken yes", request: models.ConfirmationRequest{Answer: "iya"}, wantMove: "d2d4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := llm.NewFake()
+			fake.Queue(llm.TaskMoveParsing, `{"is_valid_request": true, "from_square": "d2", "to_square": "d4", "confidence": 4}`)
+			router := newChessRouter(fake)
+
+			_, asked := post(t, router, "/ai", moveRequest("something like queen pawn"))
+			if asked.Confirmation == nil {
+				t.Fatalf("PlayChess did not ask for confirmation: %+v", asked)
+			}
+
+			tt.request.Token = asked.Confirmation.Token
+			code, response := post(t, router, "/ai/confirm", tt.request)
+			if code != http.StatusOK || response.Move != tt.wantMove || (tt.wantStatus != "" && response.Status != tt.wantStatus) {
+				t.Errorf("ConfirmMove = %d %+v, want move %q status %q", code, response, tt.wantMove, tt.wantStatus)
+			}
+
+			// Tokens can only be used once.
+			if code, _ := post(t, router, "/ai/confirm", tt.request); code != http.StatusGone {
+				t.Errorf("reusing the confirmation token gave %d, want 410", code)
+			}
+		})
+	}
+}
+
+func TestDisambiguate(t *testing.T) {
+	router := newChessRouter(llm.NewFake())
+	request := models.ChessRequest{Message: "rook d1", Fen: "4k3/8/8/8/8/8/4K3/R6R w - - 0 1", Type: "white", Mode: "easy"}
+
+	_, asked := post(t, router, "/ai", request)
+	if asked.Disambiguation == nil || len(asked.Disambiguation.Candidates) != 2 {
+		t.Fatalf("PlayChess did not ask which rook: %+v", asked)
+	}
+
+	code, response := post(t, router, "/ai/disambiguate", models.DisambiguationRequest{
+		Token:  asked.Disambiguation.Token,
+		Answer: "the one from h1",
+	})
+	if code != http.StatusOK || response.Move != "h1d1" {
+		t.Errorf("Disambiguate = %d %+v, want 200 with h1d1", code, response)
+	}
+}
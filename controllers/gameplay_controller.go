@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"log"
 	"net/http"
 
@@ -10,6 +12,8 @@ import (
 	"samsungvoicebe/services"
 )
 
+const maxPGNUploadSize = 5 << 20
+
 type GameplayController struct {
 	Config  *config.Config
 	Service *services.GameplayService
@@ -36,13 +40,13 @@ func (gc *GameplayController) PlayerMove(c *gin.Context) {
 	var err error
 
 	if gameID != "" {
-		botMove, err = gc.Service.PlayerMove(&gameID, req.Fen, req.Move, req.BotLevel)
+		botMove, err = gc.Service.PlayerMove(&gameID, req.Fen, req.Move, req.BotSettings, req.NarrationOptions, req.IncludeWarnings)
 	} else {
-		botMove, err = gc.Service.PlayerMove(nil, req.Fen, req.Move, req.BotLevel)
+		botMove, err = gc.Service.PlayerMove(nil, req.Fen, req.Move, req.BotSettings, req.NarrationOptions, req.IncludeWarnings)
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
 		log.Println("GameplayController-PlayerMove-PlayerMove", err)
 		return
 	}
@@ -52,20 +56,136 @@ func (gc *GameplayController) PlayerMove(c *gin.Context) {
 	})
 }
 
+func (gc *GameplayController) GetBotLevels(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": gc.Service.BotLevels(),
+	})
+}
+
+func (gc *GameplayController) GetBotPersonalities(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": gc.Service.BotPersonalities(),
+	})
+}
+
 func (gc *GameplayController) CreateGame(c *gin.Context) {
 	userID := c.Param("user_id")
 
-	gameID, err := gc.Service.CreateGame(userID)
+	// The body is optional: without one the player is white against the
+	// default level from the standard position.
+	var req models.CreateGameRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		log.Println("GameplayController-CreateGame-JsonBinding", err)
+		return
+	}
+
+	game, err := gc.Service.CreateGame(userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
 		log.Println("GameplayController-CreateGame-CreateGame", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"game_id": gameID,
-		},
+		"data": game,
+	})
+}
+
+func (gc *GameplayController) GetGame(c *gin.Context) {
+	gameID := c.Param("game_id")
+
+	state, err := gc.Service.GetGame(gameID)
+	if err != nil {
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
+		log.Println("GameplayController-GetGame-GetGame", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": state,
+	})
+}
+
+func (gc *GameplayController) GetUnfinishedGames(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	games, err := gc.Service.GetUnfinishedGames(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Println("GameplayController-GetUnfinishedGames-GetUnfinishedGames", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": games,
+	})
+}
+
+func (gc *GameplayController) Undo(c *gin.Context) {
+	gameID := c.Param("game_id")
+
+	var req models.UndoRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		log.Println("GameplayController-Undo-JsonBinding", err)
+		return
+	}
+
+	result, err := gc.Service.Undo(gameID, req.Plies)
+	if err != nil {
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
+		log.Println("GameplayController-Undo-Undo", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
+
+func (gc *GameplayController) Resign(c *gin.Context) {
+	gameID := c.Param("game_id")
+
+	outcome, err := gc.Service.Resign(gameID)
+	if err != nil {
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
+		log.Println("GameplayController-Resign-Resign", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": outcome,
+	})
+}
+
+func (gc *GameplayController) OfferDraw(c *gin.Context) {
+	gameID := c.Param("game_id")
+
+	result, err := gc.Service.OfferDraw(gameID)
+	if err != nil {
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
+		log.Println("GameplayController-OfferDraw-OfferDraw", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
+
+func (gc *GameplayController) AcceptDraw(c *gin.Context) {
+	gameID := c.Param("game_id")
+
+	outcome, err := gc.Service.AcceptDraw(gameID)
+	if err != nil {
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
+		log.Println("GameplayController-AcceptDraw-AcceptDraw", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": outcome,
 	})
 }
 
@@ -77,17 +197,15 @@ func (gc *GameplayController) GetHint(c *gin.Context) {
 		return
 	}
 
-	hint, err := gc.Service.GetHint(req.Fen)
+	hint, err := gc.Service.GetHint(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
 		log.Println("GameplayController-GetHint-GetHint", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"hint": hint,
-		},
+		"data": hint,
 	})
 }
 
@@ -99,9 +217,9 @@ func (gc *GameplayController) PlayerMoveByVoiceTranscription(c *gin.Context) {
 		return
 	}
 
-	playerMove, err := gc.Service.PlayerMoveByVoiceTranscription(req.Fen, req.Transcription)
+	playerMove, err := gc.Service.PlayerMoveByVoiceTranscription(req.GameID, req.Fen, req.Transcription, req.NarrationOptions)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
 		log.Println("GameplayController-PlayerMoveByVoiceTranscription-PlayerMoveByVoiceTranscription", err)
 		return
 	}
@@ -110,3 +228,124 @@ func (gc *GameplayController) PlayerMoveByVoiceTranscription(c *gin.Context) {
 		"data": playerMove,
 	})
 }
+
+func (gc *GameplayController) ResolveVoiceDisambiguation(c *gin.Context) {
+	var req models.DisambiguationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		log.Println("GameplayController-ResolveVoiceDisambiguation-JsonBinding", err)
+		return
+	}
+
+	playerMove, err := gc.Service.ResolveVoiceDisambiguation(req.Token, req.Answer)
+	if err != nil {
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
+		log.Println("GameplayController-ResolveVoiceDisambiguation-ResolveVoiceDisambiguation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": playerMove,
+	})
+}
+
+func (gc *GameplayController) DescribeBoard(c *gin.Context) {
+	var req models.BoardDescriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		log.Println("GameplayController-DescribeBoard-JsonBinding", err)
+		return
+	}
+
+	description, err := gc.Service.DescribeBoard(req)
+	if err != nil {
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
+		log.Println("GameplayController-DescribeBoard-DescribeBoard", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": description,
+	})
+}
+
+func (gc *GameplayController) GetWarnings(c *gin.Context) {
+	var req models.WarningsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		log.Println("GameplayController-GetWarnings-JsonBinding", err)
+		return
+	}
+
+	warnings, err := gc.Service.GetWarnings(req)
+	if err != nil {
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
+		log.Println("GameplayController-GetWarnings-GetWarnings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": warnings,
+	})
+}
+
+func (gc *GameplayController) ImportPGN(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	file, err := c.FormFile("pgn")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "PGN file is required"})
+		log.Println("GameplayController-ImportPGN-FormFile", err)
+		return
+	}
+
+	if file.Size > maxPGNUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "PGN file is too large"})
+		return
+	}
+
+	pgnFile, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open PGN file"})
+		log.Println("GameplayController-ImportPGN-Open", err)
+		return
+	}
+	defer pgnFile.Close()
+
+	pgnData, err := io.ReadAll(pgnFile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read PGN file"})
+		log.Println("GameplayController-ImportPGN-ReadAll", err)
+		return
+	}
+
+	result, err := gc.Service.ImportPGN(userID, string(pgnData))
+	if err != nil {
+		c.JSON(gameplayErrorStatus(err), gin.H{"error": err.Error()})
+		log.Println("GameplayController-ImportPGN-ImportPGN", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
+
+func gameplayErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrGameNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrIllegalMove), errors.Is(err, services.ErrInvalidFen), errors.Is(err, services.ErrInvalidPGN),
+		errors.Is(err, services.ErrInvalidBoardQuery), errors.Is(err, services.ErrUnknownBotLevel),
+		errors.Is(err, services.ErrUnknownPersonality), errors.Is(err, services.ErrInvalidTimeControl),
+		errors.Is(err, services.ErrInvalidUndo):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrFenMismatch), errors.Is(err, services.ErrGameOver), errors.Is(err, services.ErrNotPlayersTurn),
+		errors.Is(err, services.ErrNoDrawOffer), errors.Is(err, services.ErrNothingToUndo), errors.Is(err, services.ErrMoveConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrVoiceTokenExpired):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
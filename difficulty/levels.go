@@ -0,0 +1,199 @@
+// Package difficulty maps the bot levels players pick, by name or by target
+// Elo, to the Stockfish settings that make the engine play at that strength.
+package difficulty
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"samsungvoicebe/models"
+)
+
+// Stockfish only accepts UCI_Elo within this range; weaker play has to come
+// from Skill Level and short searches instead.
+const (
+	MinUCIElo = 1320
+	MaxUCIElo = 3190
+)
+
+// DefaultLevel is used when a request names neither a level nor an Elo.
+const DefaultLevel = "medium"
+
+// CustomLevel names the profile built for a target Elo between levels.
+const CustomLevel = "custom"
+
+var ErrInvalidLevels = errors.New("invalid bot levels")
+
+// FullStrength undoes any profile's limits. Engines are pooled and keep their
+// options between searches, so analysis searches set these first.
+var FullStrength = map[string]string{
+	"Skill Level":       "20",
+	"UCI_LimitStrength": "false",
+}
+
+var defaultProfiles = []models.BotProfile{
+	{Name: "beginner", Label: "Beginner", Elo: 800, SkillLevel: 0, Depth: 1, MoveTimeMs: 50},
+	{Name: "easy", Label: "Easy", Elo: 1100, SkillLevel: 3, Depth: 3, MoveTimeMs: 100},
+	{Name: "medium", Label: "Medium", Elo: 1500, SkillLevel: 20, UCIElo: 1500, MoveTimeMs: 300},
+	{Name: "hard", Label: "Hard", Elo: 2000, SkillLevel: 20, UCIElo: 2000, MoveTimeMs: 500},
+	{Name: "expert", Label: "Expert", Elo: 2500, SkillLevel: 20, UCIElo: 2500, MoveTimeMs: 1000},
+	{Name: "max", Label: "Maximum", Elo: 3200, SkillLevel: 20, MoveTimeMs: 2000},
+}
+
+// Levels is the list of bot levels, ordered from weakest to strongest.
+type Levels struct {
+	profiles []models.BotProfile
+}
+
+// Default returns the built-in levels.
+func Default() *Levels {
+	levels, _ := New(defaultProfiles)
+	return levels
+}
+
+// Load reads levels from a JSON file holding an array of profiles, or returns
+// the built-in levels when path is empty.
+func Load(path string) (*Levels, error) {
+	if path == "" {
+		return Default(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("difficulty-Load-ReadFile: %w", err)
+	}
+
+	var profiles []models.BotProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("difficulty-Load-Unmarshal: %w: %v", ErrInvalidLevels, err)
+	}
+
+	levels, err := New(profiles)
+	if err != nil {
+		return nil, fmt.Errorf("difficulty-Load-New: %w", err)
+	}
+	return levels, nil
+}
+
+// New validates profiles and orders them by Elo. Names are matched
+// case-insensitively and stored lowercased.
+func New(profiles []models.BotProfile) (*Levels, error) {
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("%w: no levels", ErrInvalidLevels)
+	}
+
+	seen := map[string]bool{}
+	sorted := make([]models.BotProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		profile.Name = strings.ToLower(strings.TrimSpace(profile.Name))
+		switch {
+		case profile.Name == "" || profile.Name == CustomLevel:
+			return nil, fmt.Errorf("%w: level name %q is not allowed", ErrInvalidLevels, profile.Name)
+		case seen[profile.Name]:
+			return nil, fmt.Errorf("%w: level %q is defined twice", ErrInvalidLevels, profile.Name)
+		case profile.SkillLevel < 0 || profile.SkillLevel > 20:
+			return nil, fmt.Errorf("%w: level %q has skill level %d outside 0-20", ErrInvalidLevels, profile.Name, profile.SkillLevel)
+		case profile.UCIElo != 0 && (profile.UCIElo < MinUCIElo || profile.UCIElo > MaxUCIElo):
+			return nil, fmt.Errorf("%w: level %q has UCI_Elo %d outside %d-%d", ErrInvalidLevels, profile.Name, profile.UCIElo, MinUCIElo, MaxUCIElo)
+		case profile.Depth < 0 || profile.MoveTimeMs < 0 || profile.Nodes < 0:
+			return nil, fmt.Errorf("%w: level %q has a negative search limit", ErrInvalidLevels, profile.Name)
+		case profile.Depth == 0 && profile.MoveTimeMs == 0 && profile.Nodes == 0:
+			return nil, fmt.Errorf("%w: level %q needs a depth, movetime or nodes limit", ErrInvalidLevels, profile.Name)
+		}
+		if profile.Label == "" {
+			profile.Label = profile.Name
+		}
+		seen[profile.Name] = true
+		sorted = append(sorted, profile)
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Elo < sorted[j].Elo
+	})
+
+	return &Levels{profiles: sorted}, nil
+}
+
+// List returns the levels from weakest to strongest.
+func (l *Levels) List() []models.BotProfile {
+	return append([]models.BotProfile{}, l.profiles...)
+}
+
+// Named finds a level by name.
+func (l *Levels) Named(name string) (models.BotProfile, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, profile := range l.profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return models.BotProfile{}, false
+}
+
+// ForElo builds a profile for a target rating from the level closest to it.
+// Within Stockfish's UCI_Elo range the engine is limited to exactly elo;
+// outside it the closest level is used as is.
+func (l *Levels) ForElo(elo int) models.BotProfile {
+	closest := l.profiles[0]
+	for _, profile := range l.profiles[1:] {
+		if abs(profile.Elo-elo) < abs(closest.Elo-elo) {
+			closest = profile
+		}
+	}
+
+	if elo < MinUCIElo || elo > MaxUCIElo {
+		return closest
+	}
+
+	profile := closest
+	profile.Name = CustomLevel
+	profile.Label = "Elo " + strconv.Itoa(elo)
+	profile.Elo = elo
+	profile.SkillLevel = 20
+	profile.UCIElo = elo
+	return profile
+}
+
+// Resolve picks the profile for a request: a target Elo wins over a level
+// name and with neither the default level is used. ok is false for an
+// unknown name.
+func (l *Levels) Resolve(name string, elo int) (profile models.BotProfile, ok bool) {
+	if elo > 0 {
+		return l.ForElo(elo), true
+	}
+	if strings.TrimSpace(name) == "" {
+		if profile, ok := l.Named(DefaultLevel); ok {
+			return profile, true
+		}
+		return l.profiles[len(l.profiles)/2], true
+	}
+	return l.Named(name)
+}
+
+// EngineOptions are the UCI options that make Stockfish play at profile's
+// strength.
+func EngineOptions(profile models.BotProfile) map[string]string {
+	if profile.UCIElo > 0 {
+		return map[string]string{
+			"Skill Level":       "20",
+			"UCI_LimitStrength": "true",
+			"UCI_Elo":           strconv.Itoa(profile.UCIElo),
+		}
+	}
+	return map[string]string{
+		"Skill Level":       strconv.Itoa(profile.SkillLevel),
+		"UCI_LimitStrength": "false",
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,180 @@
+package difficulty
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"samsungvoicebe/models"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []models.BotProfile
+		wantErr  bool
+	}{
+		{name: "no levels", profiles: nil, wantErr: true},
+		{name: "valid", profiles: []models.BotProfile{{Name: "easy", Elo: 1000, Depth: 2}}},
+		{name: "empty name", profiles: []models.BotProfile{{Name: " ", Depth: 2}}, wantErr: true},
+		{name: "reserved name", profiles: []models.BotProfile{{Name: "Custom", Depth: 2}}, wantErr: true},
+		{name: "duplicate name", profiles: []models.BotProfile{{Name: "easy", Depth: 2}, {Name: "EASY", Depth: 3}}, wantErr: true},
+		{name: "skill level too high", profiles: []models.BotProfile{{Name: "easy", SkillLevel: 21, Depth: 2}}, wantErr: true},
+		{name: "uci elo below range", profiles: []models.BotProfile{{Name: "easy", UCIElo: MinUCIElo - 1, Depth: 2}}, wantErr: true},
+		{name: "uci elo above range", profiles: []models.BotProfile{{Name: "easy", UCIElo: MaxUCIElo + 1, Depth: 2}}, wantErr: true},
+		{name: "negative limit", profiles: []models.BotProfile{{Name: "easy", Depth: 2, Nodes: -1}}, wantErr: true},
+		{name: "no search limit", profiles: []models.BotProfile{{Name: "easy"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.profiles)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidLevels) {
+				t.Errorf("New() error = %v, want ErrInvalidLevels", err)
+			}
+		})
+	}
+}
+
+func TestNewSortsAndNormalizes(t *testing.T) {
+	levels, err := New([]models.BotProfile{
+		{Name: " Strong ", Elo: 2000, Depth: 8},
+		{Name: "weak", Label: "Weak", Elo: 900, Depth: 1},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	want := []models.BotProfile{
+		{Name: "weak", Label: "Weak", Elo: 900, Depth: 1},
+		{Name: "strong", Label: "strong", Elo: 2000, Depth: 8},
+	}
+	if got := levels.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	levels := Default()
+
+	tests := []struct {
+		name      string
+		level     string
+		elo       int
+		wantName  string
+		wantElo   int
+		wantUCI   int
+		wantFound bool
+	}{
+		{name: "default level", wantName: "medium", wantElo: 1500, wantUCI: 1500, wantFound: true},
+		{name: "named level", level: " Hard ", wantName: "hard", wantElo: 2000, wantUCI: 2000, wantFound: true},
+		{name: "unknown level", level: "grandmaster", wantFound: false},
+		{name: "elo wins over name", level: "beginner", elo: 1800, wantName: CustomLevel, wantElo: 1800, wantUCI: 1800, wantFound: true},
+		{name: "elo below uci range uses closest level", elo: 900, wantName: "beginner", wantElo: 800, wantFound: true},
+		{name: "elo above uci range uses closest level", elo: 3300, wantName: "max", wantElo: 3200, wantFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := levels.Resolve(tt.level, tt.elo)
+			if ok != tt.wantFound {
+				t.Fatalf("Resolve(%q, %d) ok = %v, want %v", tt.level, tt.elo, ok, tt.wantFound)
+			}
+			if !ok {
+				return
+			}
+			if got.Name != tt.wantName || got.Elo != tt.wantElo || got.UCIElo != tt.wantUCI {
+				t.Errorf("Resolve(%q, %d) = %+v, want name %q elo %d uci %d", tt.level, tt.elo, got, tt.wantName, tt.wantElo, tt.wantUCI)
+			}
+		})
+	}
+}
+
+func TestForEloKeepsClosestSearchLimits(t *testing.T) {
+	// 1400 is closest to medium (1500), whose move time is kept.
+	got := Default().ForElo(1400)
+
+	want := models.BotProfile{Name: CustomLevel, Label: "Elo 1400", Elo: 1400, SkillLevel: 20, UCIElo: 1400, MoveTimeMs: 300}
+	if got != want {
+		t.Errorf("ForElo(1400) = %+v, want %+v", got, want)
+	}
+}
+
+func TestEngineOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile models.BotProfile
+		want    map[string]string
+	}{
+		{
+			name:    "skill level",
+			profile: models.BotProfile{SkillLevel: 3},
+			want:    map[string]string{"Skill Level": "3", "UCI_LimitStrength": "false"},
+		},
+		{
+			name:    "uci elo",
+			profile: models.BotProfile{SkillLevel: 5, UCIElo: 1600},
+			want:    map[string]string{"Skill Level": "20", "UCI_LimitStrength": "true", "UCI_Elo": "1600"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EngineOptions(tt.profile); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EngineOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		wantNames []string
+		wantErr   error
+	}{
+		{name: "built-in levels", path: "", wantNames: []string{"beginner", "easy", "medium", "hard", "expert", "max"}},
+		{name: "file", path: write("levels.json", `[{"name": "b", "elo": 2000, "depth": 5}, {"name": "a", "elo": 1000, "depth": 1}]`), wantNames: []string{"a", "b"}},
+		{name: "malformed file", path: write("broken.json", `{"name": "a"}`), wantErr: ErrInvalidLevels},
+		{name: "invalid level", path: write("invalid.json", `[{"name": "a"}]`), wantErr: ErrInvalidLevels},
+		{name: "missing file", path: filepath.Join(dir, "missing.json"), wantErr: os.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levels, err := Load(tt.path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			var names []string
+			for _, profile := range levels.List() {
+				names = append(names, profile.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("Load() levels = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
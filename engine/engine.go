@@ -0,0 +1,356 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEngineCrashed = errors.New("engine process exited")
+	ErrPoolTimeout   = errors.New("timed out waiting for a free engine")
+	ErrPoolClosed    = errors.New("engine pool is closed")
+)
+
+// Engine is a single long-lived UCI process. An Engine is not safe for
+// concurrent use; the Pool hands each one to a single caller at a time.
+type Engine struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	lines    chan string
+	name     string
+	options  map[string]string
+	lastGame string
+	broken   bool
+}
+
+type SearchRequest struct {
+	Fen      string
+	Depth    int
+	MoveTime time.Duration
+	Nodes    int
+	MultiPV  int
+}
+
+type Score struct {
+	CP     int  `json:"cp"`
+	Mate   int  `json:"mate,omitempty"`
+	IsMate bool `json:"is_mate"`
+}
+
+type Line struct {
+	MultiPV int      `json:"multipv"`
+	Depth   int      `json:"depth"`
+	Score   Score    `json:"score"`
+	PV      []string `json:"pv"`
+}
+
+// SearchResult holds the engine's answer. Scores are from the point of view
+// of the side to move, as reported by the engine.
+type SearchResult struct {
+	BestMove string
+	Ponder   string
+	Lines    []Line
+}
+
+func startEngine(ctx context.Context, path string, options map[string]string) (*Engine, error) {
+	cmd := exec.Command(path)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("engine-startEngine-StdinPipe: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("engine-startEngine-StdoutPipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("engine-startEngine-Start: %w", err)
+	}
+
+	e := &Engine{
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan string, 256),
+		options: map[string]string{},
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			e.lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(e.lines)
+		cmd.Wait()
+	}()
+
+	if err := e.send("uci"); err != nil {
+		e.Close()
+		return nil, fmt.Errorf("engine-startEngine-uci: %w", err)
+	}
+
+	err = e.readUntil(ctx, func(line string) bool {
+		if strings.HasPrefix(line, "id name ") {
+			e.name = strings.TrimPrefix(line, "id name ")
+		}
+		return line == "uciok"
+	})
+	if err != nil {
+		e.Close()
+		return nil, fmt.Errorf("engine-startEngine-uciok: %w", err)
+	}
+
+	if err := e.SetOptions(ctx, options); err != nil {
+		e.Close()
+		return nil, fmt.Errorf("engine-startEngine-SetOptions: %w", err)
+	}
+
+	if err := e.Ping(ctx); err != nil {
+		e.Close()
+		return nil, fmt.Errorf("engine-startEngine-Ping: %w", err)
+	}
+
+	return e, nil
+}
+
+// Name is the engine's self-reported "id name", e.g. "Stockfish 16".
+func (e *Engine) Name() string {
+	return e.name
+}
+
+// Ping sends isready and waits for readyok.
+func (e *Engine) Ping(ctx context.Context) error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	return e.readUntil(ctx, func(line string) bool {
+		return line == "readyok"
+	})
+}
+
+// SetOptions sends setoption for every option whose value differs from what
+// the engine was last given.
+func (e *Engine) SetOptions(ctx context.Context, options map[string]string) error {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	changed := false
+	for _, name := range names {
+		value := options[name]
+		if current, ok := e.options[name]; ok && current == value {
+			continue
+		}
+		if err := e.send("setoption name %s value %s", name, value); err != nil {
+			return err
+		}
+		e.options[name] = value
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return e.Ping(ctx)
+}
+
+// NewGame sends ucinewgame when the engine is about to search a position
+// from a different game than the previous search.
+func (e *Engine) NewGame(ctx context.Context, gameID string) error {
+	if gameID != "" && gameID == e.lastGame {
+		return nil
+	}
+	if err := e.send("ucinewgame"); err != nil {
+		return err
+	}
+	if err := e.Ping(ctx); err != nil {
+		return err
+	}
+	e.lastGame = gameID
+	return nil
+}
+
+// Search runs "go" on the given position and waits for bestmove. When ctx is
+// cancelled the search is stopped; an engine that does not answer the stop
+// stays marked broken so the pool replaces it.
+func (e *Engine) Search(ctx context.Context, req SearchRequest) (SearchResult, error) {
+	multiPV := req.MultiPV
+	if multiPV < 1 {
+		multiPV = 1
+	}
+	if err := e.SetOptions(ctx, map[string]string{"MultiPV": strconv.Itoa(multiPV)}); err != nil {
+		return SearchResult{}, err
+	}
+
+	if err := e.send("position fen %s", req.Fen); err != nil {
+		return SearchResult{}, err
+	}
+
+	goCmd := []string{"go"}
+	if req.Depth > 0 {
+		goCmd = append(goCmd, "depth", strconv.Itoa(req.Depth))
+	}
+	if req.Nodes > 0 {
+		goCmd = append(goCmd, "nodes", strconv.Itoa(req.Nodes))
+	}
+	if req.MoveTime > 0 {
+		goCmd = append(goCmd, "movetime", strconv.FormatInt(req.MoveTime.Milliseconds(), 10))
+	}
+	if len(goCmd) == 1 {
+		goCmd = append(goCmd, "depth", "10")
+	}
+
+	if err := e.send(strings.Join(goCmd, " ")); err != nil {
+		return SearchResult{}, err
+	}
+
+	var result SearchResult
+	lines := map[int]Line{}
+
+	handle := func(line string) bool {
+		if strings.HasPrefix(line, "info ") {
+			if info, ok := parseInfo(line); ok {
+				lines[info.MultiPV] = info
+			}
+			return false
+		}
+		if strings.HasPrefix(line, "bestmove") {
+			fields := strings.Fields(line)
+			if len(fields) > 1 {
+				result.BestMove = fields[1]
+			}
+			if len(fields) > 3 && fields[2] == "ponder" {
+				result.Ponder = fields[3]
+			}
+			return true
+		}
+		return false
+	}
+
+	err := e.readUntil(ctx, handle)
+	if err != nil && !errors.Is(err, ErrEngineCrashed) {
+		stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if sendErr := e.send("stop"); sendErr == nil && e.readUntil(stopCtx, handle) == nil {
+			e.broken = false
+		}
+	}
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	for i := 1; i <= multiPV; i++ {
+		if line, ok := lines[i]; ok {
+			result.Lines = append(result.Lines, line)
+		}
+	}
+
+	return result, nil
+}
+
+// Close asks the engine to quit and kills it if it does not exit promptly.
+func (e *Engine) Close() error {
+	e.send("quit")
+	e.stdin.Close()
+
+	select {
+	case <-e.drained():
+	case <-time.After(time.Second):
+		e.cmd.Process.Kill()
+	}
+	return nil
+}
+
+func (e *Engine) drained() <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range e.lines {
+		}
+		close(done)
+	}()
+	return done
+}
+
+func (e *Engine) send(format string, args ...any) error {
+	if _, err := fmt.Fprintf(e.stdin, format+"\n", args...); err != nil {
+		e.broken = true
+		return fmt.Errorf("%w: %v", ErrEngineCrashed, err)
+	}
+	return nil
+}
+
+func (e *Engine) readUntil(ctx context.Context, handle func(line string) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			e.broken = true
+			return ctx.Err()
+		case line, ok := <-e.lines:
+			if !ok {
+				e.broken = true
+				return ErrEngineCrashed
+			}
+			if handle(line) {
+				return nil
+			}
+		}
+	}
+}
+
+// parseInfo reads the depth, multipv, score and pv out of an "info" line.
+// Lines without a pv (currmove updates, strings) are ignored.
+func parseInfo(line string) (Line, bool) {
+	fields := strings.Fields(line)
+	info := Line{MultiPV: 1}
+	hasScore := false
+
+	for i := 1; i < len(fields); i++ {
+		switch fields[i] {
+		case "string":
+			return Line{}, false
+		case "depth":
+			if i+1 < len(fields) {
+				info.Depth, _ = strconv.Atoi(fields[i+1])
+				i++
+			}
+		case "multipv":
+			if i+1 < len(fields) {
+				info.MultiPV, _ = strconv.Atoi(fields[i+1])
+				i++
+			}
+		case "score":
+			if i+2 < len(fields) {
+				value, err := strconv.Atoi(fields[i+2])
+				if err == nil {
+					hasScore = true
+					if fields[i+1] == "mate" {
+						info.Score = Score{Mate: value, IsMate: true}
+					} else {
+						info.Score = Score{CP: value}
+					}
+				}
+				i += 2
+			}
+		case "pv":
+			info.PV = append([]string(nil), fields[i+1:]...)
+			i = len(fields)
+		}
+	}
+
+	if !hasScore || len(info.PV) == 0 {
+		return Line{}, false
+	}
+	return info, true
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   Line
+		wantOK bool
+	}{
+		{
+			name:   "centipawns",
+			line:   "info depth 12 seldepth 18 multipv 1 score cp 35 nodes 1000 pv e2e4 e7e5",
+			want:   Line{MultiPV: 1, Depth: 12, Score: Score{CP: 35}, PV: []string{"e2e4", "e7e5"}},
+			wantOK: true,
+		},
+		{
+			name:   "mate",
+			line:   "info depth 5 multipv 2 score mate -3 pv h7h8",
+			want:   Line{MultiPV: 2, Depth: 5, Score: Score{Mate: -3, IsMate: true}, PV: []string{"h7h8"}},
+			wantOK: true,
+		},
+		{
+			name:   "multipv defaults to 1",
+			line:   "info depth 1 score cp -20 pv d2d4",
+			want:   Line{MultiPV: 1, Depth: 1, Score: Score{CP: -20}, PV: []string{"d2d4"}},
+			wantOK: true,
+		},
+		{name: "bound without pv", line: "info depth 10 score cp 12 lowerbound", wantOK: false},
+		{name: "currmove", line: "info depth 10 currmove e2e4 currmovenumber 1", wantOK: false},
+		{name: "string", line: "info string NNUE evaluation using nn.nnue", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseInfo(tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("parseInfo(%q) ok = %v, want %v", tt.line, ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseInfo(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
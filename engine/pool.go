@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+type PoolConfig struct {
+	Path                string
+	Size                int
+	Options             map[string]string
+	AcquireTimeout      time.Duration
+	StartTimeout        time.Duration
+	HealthCheckInterval time.Duration
+}
+
+// Pool keeps up to Size warmed-up engines alive. Callers check an engine out
+// with Acquire and must hand it back with Release; when every engine is busy
+// Acquire queues until one is released or AcquireTimeout passes.
+type Pool struct {
+	cfg   PoolConfig
+	slots chan struct{}
+	idle  chan *Engine
+
+	mu     sync.Mutex
+	closed bool
+	name   string
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewPool(cfg PoolConfig) *Pool {
+	if cfg.Path == "" {
+		cfg.Path = "stockfish"
+	}
+	if cfg.Size < 1 {
+		cfg.Size = 1
+	}
+	if cfg.AcquireTimeout <= 0 {
+		cfg.AcquireTimeout = 10 * time.Second
+	}
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = 10 * time.Second
+	}
+
+	p := &Pool{
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.Size),
+		idle:  make(chan *Engine, cfg.Size),
+		stop:  make(chan struct{}),
+	}
+
+	if cfg.HealthCheckInterval > 0 {
+		p.wg.Add(1)
+		go p.healthLoop()
+	}
+
+	return p
+}
+
+// Warm starts engines until the pool is full so the first requests don't pay
+// for process start-up.
+func (p *Pool) Warm() error {
+	for i := 0; i < p.cfg.Size; i++ {
+		select {
+		case p.slots <- struct{}{}:
+		default:
+			return nil
+		}
+
+		e, err := p.spawn()
+		if err != nil {
+			<-p.slots
+			return fmt.Errorf("engine-Pool-Warm-spawn: %w", err)
+		}
+
+		p.idle <- e
+		<-p.slots
+	}
+	return nil
+}
+
+// Acquire checks out an engine, starting a new process if the pool has room
+// and no idle engine is available.
+func (p *Pool) Acquire(ctx context.Context) (*Engine, error) {
+	if p.isClosed() {
+		return nil, ErrPoolClosed
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, p.cfg.AcquireTimeout)
+	defer cancel()
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-waitCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, ErrPoolTimeout
+	}
+
+	select {
+	case e := <-p.idle:
+		return e, nil
+	default:
+	}
+
+	e, err := p.spawn()
+	if err != nil {
+		<-p.slots
+		return nil, fmt.Errorf("engine-Pool-Acquire-spawn: %w", err)
+	}
+	return e, nil
+}
+
+// Release returns an engine to the pool. Engines that crashed or failed to
+// stop a search are closed instead and replaced on the next Acquire.
+func (p *Pool) Release(e *Engine) {
+	defer func() { <-p.slots }()
+
+	if e.broken || p.isClosed() {
+		e.Close()
+		return
+	}
+	p.idle <- e
+}
+
+// Do runs fn with a checked-out engine and releases it afterwards.
+func (p *Pool) Do(ctx context.Context, fn func(e *Engine) error) error {
+	e, err := p.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Release(e)
+	return fn(e)
+}
+
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+
+	for {
+		select {
+		case e := <-p.idle:
+			e.Close()
+		default:
+			return
+		}
+	}
+}
+
+// EngineName is the "id name" reported by the most recently started engine,
+// or "" if no engine has started yet.
+func (p *Pool) EngineName() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.name
+}
+
+func (p *Pool) spawn() (*Engine, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.StartTimeout)
+	defer cancel()
+
+	e, err := startEngine(ctx, p.cfg.Path, p.cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.name = e.Name()
+	p.mu.Unlock()
+
+	return e, nil
+}
+
+func (p *Pool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+func (p *Pool) healthLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.checkIdle()
+		}
+	}
+}
+
+// checkIdle pings every idle engine and replaces the ones that no longer
+// answer. Busy engines are left alone; they are checked when released.
+func (p *Pool) checkIdle() {
+	for i := 0; i < p.cfg.Size; i++ {
+		select {
+		case p.slots <- struct{}{}:
+		default:
+			return
+		}
+
+		var e *Engine
+		select {
+		case e = <-p.idle:
+		default:
+			<-p.slots
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.cfg.StartTimeout)
+		err := e.Ping(ctx)
+		cancel()
+
+		if err != nil {
+			log.Println("engine-Pool-checkIdle-Ping", err)
+			e.Close()
+			e, err = p.spawn()
+			if err != nil {
+				log.Println("engine-Pool-checkIdle-spawn", err)
+				<-p.slots
+				continue
+			}
+		}
+
+		p.idle <- e
+		<-p.slots
+	}
+}
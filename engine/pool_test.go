@@ -0,0 +1,293 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const startFen = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// fakeUCIPath is the fakeuci binary built once for the package's tests.
+var fakeUCIPath string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "fakeuci")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "MkdirTemp:", err)
+		os.Exit(1)
+	}
+
+	fakeUCIPath = filepath.Join(dir, "fakeuci")
+	if output, err := exec.Command("go", "build", "-o", fakeUCIPath, "./testdata/fakeuci").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "building fakeuci: %v\n%s", err, output)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newTestPool(t *testing.T, cfg PoolConfig) *Pool {
+	t.Helper()
+	cfg.Path = fakeUCIPath
+	pool := NewPool(cfg)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// commandLog makes the engines started from now on log the commands they
+// receive and returns a function reading them back.
+func commandLog(t *testing.T) func() []string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "commands.log")
+	t.Setenv("FAKEUCI_LOG", path)
+
+	return func() []string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading command log: %v", err)
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+func count(lines []string, command string) int {
+	n := 0
+	for _, line := range lines {
+		if line == command {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPoolReusesReleasedEngine(t *testing.T) {
+	pool := newTestPool(t, PoolConfig{Size: 1})
+
+	first, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if got := pool.EngineName(); got != "FakeUCI 1.0" {
+		t.Errorf("EngineName() = %q, want %q", got, "FakeUCI 1.0")
+	}
+	pool.Release(first)
+
+	second, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	defer pool.Release(second)
+
+	if second != first {
+		t.Error("Acquire started a new engine instead of reusing the released one")
+	}
+}
+
+func TestPoolSearch(t *testing.T) {
+	pool := newTestPool(t, PoolConfig{Size: 1})
+
+	var result SearchResult
+	err := pool.Do(context.Background(), func(e *Engine) error {
+		var err error
+		result, err = e.Search(context.Background(), SearchRequest{Fen: startFen, Depth: 1})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	if result.BestMove == "" {
+		t.Fatal("Search returned no best move")
+	}
+	if len(result.Lines) != 1 || result.Lines[0].PV[0] != result.BestMove {
+		t.Errorf("Search lines = %+v, want one line starting with %s", result.Lines, result.BestMove)
+	}
+}
+
+func TestPoolQueuesUntilRelease(t *testing.T) {
+	pool := newTestPool(t, PoolConfig{Size: 1, AcquireTimeout: 2 * time.Second})
+
+	held, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	acquired := make(chan *Engine)
+	go func() {
+		e, err := pool.Acquire(context.Background())
+		if err != nil {
+			t.Errorf("queued Acquire: %v", err)
+		}
+		acquired <- e
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Acquire returned while every engine was checked out")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.Release(held)
+
+	select {
+	case e := <-acquired:
+		if e != held {
+			t.Error("queued Acquire did not get the released engine")
+		}
+		pool.Release(e)
+	case <-time.After(time.Second):
+		t.Fatal("queued Acquire did not return after Release")
+	}
+}
+
+func TestPoolAcquireTimeout(t *testing.T) {
+	pool := newTestPool(t, PoolConfig{Size: 1, AcquireTimeout: 50 * time.Millisecond})
+
+	held, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer pool.Release(held)
+
+	start := time.Now()
+	_, err = pool.Acquire(context.Background())
+	if !errors.Is(err, ErrPoolTimeout) {
+		t.Fatalf("Acquire on a full pool = %v, want ErrPoolTimeout", err)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("Acquire gave up after %v, before the acquire timeout", waited)
+	}
+}
+
+func TestPoolAcquireCancelled(t *testing.T) {
+	pool := newTestPool(t, PoolConfig{Size: 1, AcquireTimeout: time.Second})
+
+	held, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer pool.Release(held)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestPoolReplacesCrashedEngine(t *testing.T) {
+	t.Setenv("FAKEUCI_CRASH_AFTER", "1")
+	pool := newTestPool(t, PoolConfig{Size: 1})
+	search := SearchRequest{Fen: startFen, Depth: 1}
+
+	crashed, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, err := crashed.Search(context.Background(), search); err != nil {
+		t.Fatalf("first Search: %v", err)
+	}
+	if _, err := crashed.Search(context.Background(), search); !errors.Is(err, ErrEngineCrashed) {
+		t.Fatalf("Search on a crashing engine = %v, want ErrEngineCrashed", err)
+	}
+	pool.Release(crashed)
+
+	replacement, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire after a crash: %v", err)
+	}
+	defer pool.Release(replacement)
+
+	if replacement == crashed {
+		t.Fatal("the crashed engine was handed out again")
+	}
+	if _, err := replacement.Search(context.Background(), search); err != nil {
+		t.Errorf("Search on the replacement engine: %v", err)
+	}
+}
+
+func TestPoolHealthCheckReplacesDeadEngine(t *testing.T) {
+	pool := newTestPool(t, PoolConfig{Size: 1, HealthCheckInterval: 20 * time.Millisecond})
+
+	e, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	e.cmd.Process.Kill()
+	pool.Release(e)
+
+	time.Sleep(100 * time.Millisecond)
+
+	replacement, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer pool.Release(replacement)
+
+	if replacement == e {
+		t.Fatal("the health check kept an engine that no longer answers")
+	}
+	if err := replacement.Ping(context.Background()); err != nil {
+		t.Errorf("Ping on the replacement engine: %v", err)
+	}
+}
+
+func TestEngineNewGameOnReuse(t *testing.T) {
+	commands := commandLog(t)
+	pool := newTestPool(t, PoolConfig{Size: 1})
+	ctx := context.Background()
+
+	for _, gameID := range []string{"game-1", "game-1", "game-2", ""} {
+		err := pool.Do(ctx, func(e *Engine) error {
+			if err := e.NewGame(ctx, gameID); err != nil {
+				return err
+			}
+			_, err := e.Search(ctx, SearchRequest{Fen: startFen, Depth: 1})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("searching for %q: %v", gameID, err)
+		}
+	}
+
+	// game-1 once, game-2 once and once for the search outside any game.
+	if got := count(commands(), "ucinewgame"); got != 3 {
+		t.Errorf("ucinewgame sent %d times, want 3", got)
+	}
+}
+
+func TestEngineSetOptionsOnlyWhenChanged(t *testing.T) {
+	commands := commandLog(t)
+	pool := newTestPool(t, PoolConfig{Size: 1})
+	ctx := context.Background()
+
+	err := pool.Do(ctx, func(e *Engine) error {
+		for _, level := range []string{"5", "5", "20"} {
+			if err := e.SetOptions(ctx, map[string]string{"Skill Level": level}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SetOptions: %v", err)
+	}
+
+	lines := commands()
+	if got := count(lines, "setoption name Skill Level value 5"); got != 1 {
+		t.Errorf("Skill Level 5 sent %d times, want 1", got)
+	}
+	if got := count(lines, "setoption name Skill Level value 20"); got != 1 {
+		t.Errorf("Skill Level 20 sent %d times, want 1", got)
+	}
+}
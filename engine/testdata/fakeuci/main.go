@@ -0,0 +1,106 @@
+// Command fakeuci is a minimal UCI engine for exercising the engine pool
+// without Stockfish. It always plays the first legal move and reports a
+// score of 0. Set FAKEUCI_DELAY (e.g. "500ms") to slow down searches,
+// FAKEUCI_CRASH_AFTER to exit after that many searches and FAKEUCI_LOG to a
+// file that every command received is appended to.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+func main() {
+	delay, _ := time.ParseDuration(os.Getenv("FAKEUCI_DELAY"))
+	crashAfter, _ := strconv.Atoi(os.Getenv("FAKEUCI_CRASH_AFTER"))
+
+	var commandLog *os.File
+	if path := os.Getenv("FAKEUCI_LOG"); path != "" {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "fakeuci:", err)
+			os.Exit(2)
+		}
+		defer file.Close()
+		commandLog = file
+	}
+
+	game := chess.NewGame()
+	searches := 0
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if commandLog != nil {
+			fmt.Fprintln(commandLog, strings.Join(fields, " "))
+		}
+
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name FakeUCI 1.0")
+			fmt.Println("id author samsungvoicebe")
+			fmt.Println("option name MultiPV type spin default 1 min 1 max 500")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "ucinewgame":
+			game = chess.NewGame()
+		case "position":
+			game = position(fields[1:])
+		case "go":
+			searches++
+			if crashAfter > 0 && searches > crashAfter {
+				os.Exit(1)
+			}
+			time.Sleep(delay)
+			moves := game.ValidMoves()
+			if len(moves) == 0 {
+				fmt.Println("bestmove (none)")
+				continue
+			}
+			fmt.Printf("info depth 1 multipv 1 score cp 0 pv %s\n", moves[0].String())
+			fmt.Printf("bestmove %s\n", moves[0].String())
+		case "quit":
+			return
+		}
+	}
+}
+
+func position(args []string) *chess.Game {
+	game := chess.NewGame(chess.UseNotation(chess.UCINotation{}))
+	if len(args) == 0 {
+		return game
+	}
+
+	rest := args[1:]
+	if args[0] == "fen" {
+		end := len(rest)
+		for i, arg := range rest {
+			if arg == "moves" {
+				end = i
+				break
+			}
+		}
+		fenOption, err := chess.FEN(strings.Join(rest[:end], " "))
+		if err == nil {
+			game = chess.NewGame(fenOption, chess.UseNotation(chess.UCINotation{}))
+		}
+		rest = rest[end:]
+	}
+
+	if len(rest) > 0 && rest[0] == "moves" {
+		for _, move := range rest[1:] {
+			game.MoveStr(move)
+		}
+	}
+	return game
+}
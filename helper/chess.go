@@ -0,0 +1,96 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// DecodeMove parses a move written in UCI (e2e4, e7e8q) or SAN (e4, Nf3, O-O)
+// and returns the matching legal move for the given position.
+func DecodeMove(position *chess.Position, move string) (*chess.Move, error) {
+	move = strings.TrimSpace(move)
+	if move == "" {
+		return nil, fmt.Errorf("helper-DecodeMove: empty move")
+	}
+
+	validMoves := position.ValidMoves()
+
+	if uciMove, err := (chess.UCINotation{}).Decode(position, strings.ToLower(move)); err == nil {
+		for _, validMove := range validMoves {
+			if validMove.String() == uciMove.String() {
+				return validMove, nil
+			}
+		}
+	}
+
+	sanMove, err := (chess.AlgebraicNotation{}).Decode(position, move)
+	if err != nil {
+		return nil, fmt.Errorf("helper-DecodeMove: %s is not a legal move in %s", move, position.String())
+	}
+
+	return sanMove, nil
+}
+
+// NormalizeFEN reduces a FEN to the fields that identify a position (placement,
+// side to move, castling rights, en passant) so FENs written by different
+// clients compare equal. The en passant square is only kept when an en passant
+// capture is actually legal, and the move counters are dropped.
+func NormalizeFEN(fen string) (string, error) {
+	fenOption, err := chess.FEN(fen)
+	if err != nil {
+		return "", fmt.Errorf("helper-NormalizeFEN-chess.FEN: %w", err)
+	}
+
+	position := chess.NewGame(fenOption).Position()
+	fields := strings.Fields(position.String())
+
+	enPassant := "-"
+	for _, move := range position.ValidMoves() {
+		if move.HasTag(chess.EnPassant) {
+			enPassant = fields[3]
+			break
+		}
+	}
+
+	return strings.Join([]string{fields[0], fields[1], fields[2], enPassant}, " "), nil
+}
+
+// SameFEN reports whether two FENs describe the same position.
+func SameFEN(a, b string) bool {
+	normalizedA, err := NormalizeFEN(a)
+	if err != nil {
+		return false
+	}
+	normalizedB, err := NormalizeFEN(b)
+	if err != nil {
+		return false
+	}
+	return normalizedA == normalizedB
+}
+
+// ParseTimeControl reads a PGN time control such as "300" or "300+5": the
+// base time and the increment per move, both in seconds.
+func ParseTimeControl(timeControl string) (base, increment time.Duration, err error) {
+	baseText, incrementText, hasIncrement := strings.Cut(strings.TrimSpace(timeControl), "+")
+
+	baseSeconds, err := strconv.Atoi(baseText)
+	if err != nil || baseSeconds <= 0 {
+		return 0, 0, fmt.Errorf("helper-ParseTimeControl: %q needs a positive number of seconds", timeControl)
+	}
+
+	incrementSeconds := 0
+	if hasIncrement {
+		incrementSeconds, err = strconv.Atoi(incrementText)
+		if err != nil || incrementSeconds < 0 {
+			return 0, 0, fmt.Errorf("helper-ParseTimeControl: %q has an invalid increment", timeControl)
+		}
+	}
+
+	return time.Duration(baseSeconds) * time.Second, time.Duration(incrementSeconds) * time.Second, nil
+}
@@ -0,0 +1,152 @@
+package helper
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+type PGNTag struct {
+	Key   string
+	Value string
+}
+
+// EncodePGN writes the game as export-format PGN: the given tags, then the
+// SAN move text wrapped at 80 columns and terminated by the result. Move
+// numbers follow the starting position, so games that start from a FEN with
+// black to move begin with "N...".
+func EncodePGN(tags []PGNTag, game *chess.Game, result string) string {
+	var pgn strings.Builder
+
+	for _, tag := range tags {
+		value := strings.ReplaceAll(tag.Value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		fmt.Fprintf(&pgn, "[%s \"%s\"]\n", tag.Key, value)
+	}
+	pgn.WriteString("\n")
+
+	var tokens []string
+	positions := game.Positions()
+	for i, move := range game.Moves() {
+		position := positions[i]
+		moveNumber := fullMoveNumber(position)
+
+		if position.Turn() == chess.White {
+			tokens = append(tokens, fmt.Sprintf("%d.", moveNumber))
+		} else if i == 0 {
+			tokens = append(tokens, fmt.Sprintf("%d...", moveNumber))
+		}
+		tokens = append(tokens, chess.AlgebraicNotation{}.Encode(position, move))
+	}
+	tokens = append(tokens, result)
+
+	lineLength := 0
+	for i, token := range tokens {
+		if i > 0 {
+			if lineLength+1+len(token) > 80 {
+				pgn.WriteString("\n")
+				lineLength = 0
+			} else {
+				pgn.WriteString(" ")
+				lineLength++
+			}
+		}
+		pgn.WriteString(token)
+		lineLength += len(token)
+	}
+	pgn.WriteString("\n")
+
+	return pgn.String()
+}
+
+func fullMoveNumber(position *chess.Position) int {
+	fields := strings.Fields(position.String())
+	var moveNumber int
+	fmt.Sscanf(fields[5], "%d", &moveNumber)
+	return moveNumber
+}
+
+// SplitPGN splits a PGN file into the text of its individual games. A game
+// ends at the result token closing its move text (1-0, 0-1, 1/2-1/2 or *) and
+// at a tag section that follows move text; text after the last result is kept
+// as a game of its own.
+func SplitPGN(pgn string) []string {
+	var games []string
+	var current strings.Builder
+	inMoves := false
+	inComment := false
+
+	flush := func() {
+		if text := strings.TrimSpace(current.String()); text != "" {
+			games = append(games, text)
+		}
+		current.Reset()
+		inMoves = false
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(pgn, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || (!inComment && strings.HasPrefix(trimmed, "%")) {
+			continue
+		}
+
+		if !inComment && strings.HasPrefix(trimmed, "[") {
+			if inMoves {
+				flush()
+			}
+			current.WriteString(trimmed)
+			current.WriteString("\n")
+			continue
+		}
+
+		start := 0
+		for _, end := range resultEnds(trimmed, &inComment) {
+			current.WriteString(trimmed[start:end])
+			flush()
+			start = end
+		}
+		if rest := strings.TrimSpace(trimmed[start:]); rest != "" {
+			current.WriteString(rest)
+			current.WriteString("\n")
+			inMoves = true
+		}
+	}
+	flush()
+
+	return games
+}
+
+// resultEnds returns the offsets just past each game result token in a line
+// of move text. Tokens inside {} comments, which may span lines as tracked by
+// inComment, and after a ; comment are skipped.
+func resultEnds(line string, inComment *bool) []int {
+	var ends []int
+	for i := 0; i < len(line); {
+		switch {
+		case *inComment:
+			if line[i] == '}' {
+				*inComment = false
+			}
+			i++
+		case line[i] == '{':
+			*inComment = true
+			i++
+		case line[i] == ';':
+			return ends
+		case strings.ContainsRune(" \t()", rune(line[i])):
+			i++
+		default:
+			end := i
+			for end < len(line) && !strings.ContainsRune(" \t(){;", rune(line[end])) {
+				end++
+			}
+			switch line[i:end] {
+			case "1-0", "0-1", "1/2-1/2", "*":
+				ends = append(ends, end)
+			}
+			i = end
+		}
+	}
+	return ends
+}
@@ -0,0 +1,154 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func playGame(t *testing.T, fen string, moves ...string) *chess.Game {
+	t.Helper()
+	fenOption, err := chess.FEN(fen)
+	if err != nil {
+		t.Fatalf("chess.FEN(%q): %v", fen, err)
+	}
+
+	game := chess.NewGame(fenOption)
+	for _, move := range moves {
+		if err := game.MoveStr(move); err != nil {
+			t.Fatalf("playing %s: %v", move, err)
+		}
+	}
+	return game
+}
+
+func TestEncodePGN(t *testing.T) {
+	longGame := []string{
+		"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O", "Be7",
+		"Re1", "b5", "Bb3", "d6", "c3", "O-O", "h3", "Nb8", "d4", "Nbd7",
+	}
+
+	tests := []struct {
+		name  string
+		tags  []PGNTag
+		fen   string
+		moves []string
+		want  string
+	}{
+		{
+			name:  "tags and moves",
+			tags:  []PGNTag{{"Event", "Casual"}, {"Result", "0-1"}},
+			fen:   StartingFEN,
+			moves: []string{"f3", "e5", "g4", "Qh4#"},
+			want:  "[Event \"Casual\"]\n[Result \"0-1\"]\n\n1. f3 e5 2. g4 Qh4# 0-1\n",
+		},
+		{
+			name: "escaped tag values",
+			tags: []PGNTag{{"White", `Ann "The Rook" \ Lee`}},
+			fen:  StartingFEN,
+			want: "[White \"Ann \\\"The Rook\\\" \\\\ Lee\"]\n\n*\n",
+		},
+		{
+			name:  "black to move first",
+			fen:   "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12",
+			moves: []string{"Kd7", "e4"},
+			want:  "\n12... Kd7 13. e4 *\n",
+		},
+		{
+			name:  "move text wraps at 80 columns",
+			fen:   StartingFEN,
+			moves: longGame,
+			want: "\n1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Ba4 Nf6 5. O-O Be7 6. Re1 b5 7. Bb3 d6 8. c3\n" +
+				"O-O 9. h3 Nb8 10. d4 Nbd7 *\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := "*"
+			for _, tag := range tt.tags {
+				if tag.Key == "Result" {
+					result = tag.Value
+				}
+			}
+
+			got := EncodePGN(tt.tags, playGame(t, tt.fen, tt.moves...), result)
+			if got != tt.want {
+				t.Errorf("EncodePGN() =\n%s\nwant\n%s", got, tt.want)
+			}
+			for _, line := range strings.Split(got, "\n") {
+				if len(line) > 80 {
+					t.Errorf("EncodePGN() line longer than 80 columns: %q", line)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodePGNRoundTrip(t *testing.T) {
+	game := playGame(t, "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12", "Kd7", "e4", "Ke6")
+	pgn := EncodePGN([]PGNTag{{"SetUp", "1"}, {"FEN", "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12"}}, game, "*")
+
+	pgnOption, err := chess.PGN(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatalf("chess.PGN(%q): %v", pgn, err)
+	}
+	if got, want := chess.NewGame(pgnOption).Position().String(), game.Position().String(); got != want {
+		t.Errorf("re-reading the PGN reached %s, want %s", got, want)
+	}
+}
+
+func TestSplitPGN(t *testing.T) {
+	tests := []struct {
+		name string
+		pgn  string
+		want []string
+	}{
+		{name: "empty", pgn: "\n  \n", want: nil},
+		{
+			name: "one game",
+			pgn:  "[Event \"A\"]\n[Result \"1-0\"]\n\n1. e4 e5\n2. Qh5 1-0\n",
+			want: []string{"[Event \"A\"]\n[Result \"1-0\"]\n1. e4 e5\n2. Qh5 1-0"},
+		},
+		{
+			name: "games with tags",
+			pgn:  "[Event \"A\"]\n\n1. e4 e5 1-0\n\n[Event \"B\"]\n\n1. d4 d5 0-1\n",
+			want: []string{"[Event \"A\"]\n1. e4 e5 1-0", "[Event \"B\"]\n1. d4 d5 0-1"},
+		},
+		{
+			name: "tagless game after a result",
+			pgn:  "[Event \"A\"]\n\n1. e4 e5 1-0\n\n1. d4 d5 1/2-1/2\n",
+			want: []string{"[Event \"A\"]\n1. e4 e5 1-0", "1. d4 d5 1/2-1/2"},
+		},
+		{
+			name: "games on one line",
+			pgn:  "1. e4 e5 * 1. d4 d5 0-1 1. c4",
+			want: []string{"1. e4 e5 *", "1. d4 d5 0-1", "1. c4"},
+		},
+		{
+			name: "tags without a result between games",
+			pgn:  "[Event \"A\"]\n1. e4 e5\n[Event \"B\"]\n1. d4 d5",
+			want: []string{"[Event \"A\"]\n1. e4 e5", "[Event \"B\"]\n1. d4 d5"},
+		},
+		{
+			name: "results inside comments",
+			pgn:  "1. e4 {1-0 would be early} e5 ; 0-1\n2. Nf3 {a comment\n[over two lines] *} Nc6 *\n1. d4 *",
+			want: []string{"1. e4 {1-0 would be early} e5 ; 0-1\n2. Nf3 {a comment\n[over two lines] *} Nc6 *", "1. d4 *"},
+		},
+		{
+			name: "escape lines and windows line endings",
+			pgn:  "% exported by a tool\r\n[Event \"A\"]\r\n\r\n1. e4 *\r\n",
+			want: []string{"[Event \"A\"]\n1. e4 *"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitPGN(tt.pgn); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitPGN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
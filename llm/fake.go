@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+type Call struct {
+	Task   Task
+	Prompt string
+	Image  *Image
+}
+
+// Fake is an in-memory LLM for tests and local development. Responses are
+// queued per task; when a task's queue is empty Default is returned. Every
+// call is recorded in Calls.
+type Fake struct {
+	mu        sync.Mutex
+	responses map[Task][]string
+	Default   string
+	Err       error
+	Calls     []Call
+}
+
+func NewFake() *Fake {
+	return &Fake{responses: map[Task][]string{}}
+}
+
+// Queue adds responses that the next calls for task will return in order.
+func (f *Fake) Queue(task Task, responses ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[task] = append(f.responses[task], responses...)
+}
+
+func (f *Fake) Generate(ctx context.Context, task Task, prompt string) (string, error) {
+	return f.next(Call{Task: task, Prompt: prompt})
+}
+
+func (f *Fake) GenerateWithImage(ctx context.Context, task Task, prompt string, image Image) (string, error) {
+	return f.next(Call{Task: task, Prompt: prompt, Image: &image})
+}
+
+func (f *Fake) GenerateJSON(ctx context.Context, task Task, prompt string, out any) error {
+	response, err := f.next(Call{Task: task, Prompt: prompt})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(StripCodeFence(response)), out); err != nil {
+		return fmt.Errorf("llm-Fake-GenerateJSON-json.Unmarshal: %w", err)
+	}
+	return nil
+}
+
+func (f *Fake) next(call Call) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Calls = append(f.Calls, call)
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	queue := f.responses[call.Task]
+	if len(queue) == 0 {
+		return f.Default, nil
+	}
+	f.responses[call.Task] = queue[1:]
+	return queue[0], nil
+}
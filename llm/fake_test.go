@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFakeQueue(t *testing.T) {
+	fake := NewFake()
+	fake.Default = "default"
+	fake.Queue(TaskMoveParsing, "e2e4", "g1f3")
+	fake.Queue(TaskHint, "develop your knights")
+	fake.Queue(TaskMoveParsing, "d2d4")
+
+	ctx := context.Background()
+	steps := []struct {
+		task Task
+		want string
+	}{
+		{TaskMoveParsing, "e2e4"},
+		{TaskHint, "develop your knights"},
+		{TaskMoveParsing, "g1f3"},
+		{TaskMoveParsing, "d2d4"},
+		{TaskMoveParsing, "default"},
+		{TaskHint, "default"},
+		{TaskChat, "default"},
+	}
+
+	for i, step := range steps {
+		got, err := fake.Generate(ctx, step.task, "prompt")
+		if err != nil {
+			t.Fatalf("call %d: Generate: %v", i+1, err)
+		}
+		if got != step.want {
+			t.Errorf("call %d: Generate(%s) = %q, want %q", i+1, step.task, got, step.want)
+		}
+	}
+	if len(fake.Calls) != len(steps) {
+		t.Errorf("recorded %d calls, want %d", len(fake.Calls), len(steps))
+	}
+}
+
+func TestFakeGenerateJSON(t *testing.T) {
+	fake := NewFake()
+	fake.Queue(TaskChat, "```json\n{\"move\": \"e2e4\"}\n```", "not json")
+
+	var out struct {
+		Move string `json:"move"`
+	}
+	if err := fake.GenerateJSON(context.Background(), TaskChat, "prompt", &out); err != nil {
+		t.Fatalf("GenerateJSON: %v", err)
+	}
+	if out.Move != "e2e4" {
+		t.Errorf("GenerateJSON() move = %q, want e2e4", out.Move)
+	}
+	if err := fake.GenerateJSON(context.Background(), TaskChat, "prompt", &out); err == nil {
+		t.Error("GenerateJSON() decoded a response that is not JSON")
+	}
+}
+
+func TestFakeErr(t *testing.T) {
+	fake := NewFake()
+	fake.Queue(TaskChat, "hello")
+	fake.Err = errors.New("quota exceeded")
+
+	if _, err := fake.GenerateWithImage(context.Background(), TaskVision, "prompt", Image{}); !errors.Is(err, fake.Err) {
+		t.Errorf("GenerateWithImage() error = %v, want %v", err, fake.Err)
+	}
+	if len(fake.Calls) != 1 || fake.Calls[0].Image == nil {
+		t.Errorf("calls = %+v, want one call with an image", fake.Calls)
+	}
+
+	fake.Err = nil
+	if got, _ := fake.Generate(context.Background(), TaskChat, "prompt"); got != "hello" {
+		t.Errorf("Generate() after a failure = %q, want the queued %q", got, "hello")
+	}
+}
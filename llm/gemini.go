@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/api/option"
+)
+
+type GeminiConfig struct {
+	APIKey         string
+	DefaultModel   string
+	Models         map[Task]string
+	Timeout        time.Duration
+	MaxRetries     int
+	InitialBackoff time.Duration
+}
+
+// Gemini implements LLM on top of a single shared genai client.
+type Gemini struct {
+	client *genai.Client
+	cfg    GeminiConfig
+}
+
+func NewGemini(ctx context.Context, cfg GeminiConfig) (*Gemini, error) {
+	if cfg.DefaultModel == "" {
+		cfg.DefaultModel = "gemini-2.5-pro"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 500 * time.Millisecond
+	}
+
+	client, err := genai.NewClient(ctx, option.WithAPIKey(cfg.APIKey))
+	if err != nil {
+		return nil, fmt.Errorf("llm-NewGemini-genai.NewClient: %w", err)
+	}
+
+	return &Gemini{client: client, cfg: cfg}, nil
+}
+
+func (g *Gemini) Close() error {
+	return g.client.Close()
+}
+
+func (g *Gemini) Generate(ctx context.Context, task Task, prompt string) (string, error) {
+	model := g.model(task)
+	return g.generate(ctx, model, genai.Text(prompt))
+}
+
+func (g *Gemini) GenerateWithImage(ctx context.Context, task Task, prompt string, image Image) (string, error) {
+	mimeType := image.MIMEType
+	if mimeType == "" {
+		mimeType = http.DetectContentType(image.Data)
+	}
+
+	model := g.model(task)
+	return g.generate(ctx, model, genai.Text(prompt), genai.Blob{MIMEType: mimeType, Data: image.Data})
+}
+
+func (g *Gemini) GenerateJSON(ctx context.Context, task Task, prompt string, out any) error {
+	model := g.model(task)
+	model.ResponseMIMEType = "application/json"
+
+	response, err := g.generate(ctx, model, genai.Text(prompt))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(StripCodeFence(response)), out); err != nil {
+		return fmt.Errorf("llm-Gemini-GenerateJSON-json.Unmarshal: %w", err)
+	}
+	return nil
+}
+
+func (g *Gemini) model(task Task) *genai.GenerativeModel {
+	name := g.cfg.Models[task]
+	if name == "" {
+		name = g.cfg.DefaultModel
+	}
+	return g.client.GenerativeModel(name)
+}
+
+// generate calls the model with a per-attempt timeout and retries failed
+// calls with exponential backoff until MaxRetries is exhausted or ctx ends.
+func (g *Gemini) generate(ctx context.Context, model *genai.GenerativeModel, parts ...genai.Part) (string, error) {
+	backoff := g.cfg.InitialBackoff
+
+	var lastErr error
+	for attempt := 0; attempt <= g.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return "", fmt.Errorf("llm-Gemini-generate: %w (last error: %v)", ctx.Err(), lastErr)
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		response, err := g.generateOnce(ctx, model, parts...)
+		if err == nil {
+			return response, nil
+		}
+		if ctx.Err() != nil || !retryable(err) {
+			return "", err
+		}
+		lastErr = err
+	}
+
+	return "", fmt.Errorf("llm-Gemini-generate: giving up after %d attempts: %w", g.cfg.MaxRetries+1, lastErr)
+}
+
+// retryable reports whether a failed call is worth repeating: rate limits,
+// server errors and transport failures are, rejected requests are not, and
+// neither are prompts or answers blocked by the safety filters, which block
+// them again.
+func retryable(err error) bool {
+	if errors.Is(err, ErrEmptyResponse) {
+		return true
+	}
+
+	var blockedErr *genai.BlockedError
+	if errors.As(err, &blockedErr) {
+		return false
+	}
+
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		code := apiErr.HTTPCode()
+		return code == -1 || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+
+	return true
+}
+
+func (g *Gemini) generateOnce(ctx context.Context, model *genai.GenerativeModel, parts ...genai.Part) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.cfg.Timeout)
+	defer cancel()
+
+	resp, err := model.GenerateContent(ctx, parts...)
+	if err != nil {
+		return "", fmt.Errorf("llm-Gemini-generateOnce-GenerateContent: %w", err)
+	}
+
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", ErrEmptyResponse
+	}
+
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if t, ok := part.(genai.Text); ok {
+			text.WriteString(string(t))
+		}
+	}
+
+	if text.Len() == 0 {
+		return "", ErrEmptyResponse
+	}
+	return text.String(), nil
+}
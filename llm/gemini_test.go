@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/api/googleapi"
+)
+
+func TestRetryable(t *testing.T) {
+	apiError := func(code int) error {
+		err, ok := apierror.FromError(&googleapi.Error{Code: code})
+		if !ok {
+			t.Fatalf("apierror.FromError(%d) failed", code)
+		}
+		return fmt.Errorf("llm-Gemini-generateOnce-GenerateContent: %w", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"empty response", ErrEmptyResponse, true},
+		{"rate limited", apiError(http.StatusTooManyRequests), true},
+		{"server error", apiError(http.StatusServiceUnavailable), true},
+		{"bad request", apiError(http.StatusBadRequest), false},
+		{"permission denied", apiError(http.StatusForbidden), false},
+		{"blocked prompt", fmt.Errorf("generate: %w", &genai.BlockedError{PromptFeedback: &genai.PromptFeedback{}}), false},
+		{"blocked answer", &genai.BlockedError{Candidate: &genai.Candidate{FinishReason: genai.FinishReasonSafety}}, false},
+		{"transport failure", errors.New("connection reset by peer"), true},
+		{"deadline", context.DeadlineExceeded, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
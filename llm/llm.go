@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Task names a kind of prompt. Each task can be routed to its own model.
+type Task string
+
+const (
+	TaskChat        Task = "chat"
+	TaskMoveParsing Task = "move_parsing"
+	TaskHint        Task = "hint"
+	TaskVision      Task = "vision"
+)
+
+var ErrEmptyResponse = errors.New("llm: no response generated")
+
+type Image struct {
+	Data     []byte
+	MIMEType string
+}
+
+// LLM is the interface every service that prompts a language model depends on.
+type LLM interface {
+	// Generate returns the model's text answer to the prompt.
+	Generate(ctx context.Context, task Task, prompt string) (string, error)
+	// GenerateWithImage sends the prompt together with an image.
+	GenerateWithImage(ctx context.Context, task Task, prompt string, image Image) (string, error)
+	// GenerateJSON asks for a JSON answer and unmarshals it into out.
+	GenerateJSON(ctx context.Context, task Task, prompt string, out any) error
+}
+
+// StripCodeFence removes a surrounding ```json ... ``` block that models
+// like to wrap JSON answers in.
+func StripCodeFence(response string) string {
+	response = strings.TrimSpace(response)
+
+	if strings.HasPrefix(response, "```json") {
+		response = strings.TrimPrefix(response, "```json")
+		response = strings.TrimSuffix(response, "```")
+	} else if strings.HasPrefix(response, "```") {
+		response = strings.TrimPrefix(response, "```")
+		response = strings.TrimSuffix(response, "```")
+	}
+
+	return strings.TrimSpace(response)
+}
@@ -0,0 +1,26 @@
+package llm
+
+import "testing"
+
+func TestStripCodeFence(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"plain", `{"move": "e2e4"}`, `{"move": "e2e4"}`},
+		{"json fence", "```json\n{\"move\": \"e2e4\"}\n```", `{"move": "e2e4"}`},
+		{"bare fence", "```\n{\"move\": \"e2e4\"}\n```", `{"move": "e2e4"}`},
+		{"surrounding whitespace", "\n  ```json\n{}\n```  \n", "{}"},
+		{"unclosed fence", "```json\n{}", "{}"},
+		{"fence inside the answer is kept", "e2e4 ```", "e2e4 ```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripCodeFence(tt.response); got != tt.want {
+				t.Errorf("StripCodeFence(%q) = %q, want %q", tt.response, got, tt.want)
+			}
+		})
+	}
+}
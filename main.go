@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"os"
 
 	"samsungvoicebe/config"
 	"samsungvoicebe/db"
+	"samsungvoicebe/difficulty"
+	"samsungvoicebe/engine"
+	"samsungvoicebe/llm"
 	"samsungvoicebe/middleware"
+	"samsungvoicebe/migrate"
+	"samsungvoicebe/models"
+	"samsungvoicebe/pending"
 	"samsungvoicebe/repo"
 	"samsungvoicebe/routes"
+	"samsungvoicebe/schema"
 	"samsungvoicebe/services"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +26,14 @@ import (
 func main() {
 	cfg := config.LoadConfig()
 
+	autoMigrate := flag.Bool("auto-migrate", cfg.AutoMigrate, "apply pending database migrations before starting the server")
+	flag.Parse()
+
+	if flag.Arg(0) == "migrate" {
+		runMigrations(flag.Args()[1:])
+		return
+	}
+
 	if cfg.IsValid() {
 		log.Println("✅ Environment loaded successfully")
 	} else {
@@ -30,12 +48,63 @@ func main() {
 
 	log.Println("✅ Database connected successfully")
 
+	if *autoMigrate {
+		migrator, err := migrate.New(database, schema.Migrations)
+		if err != nil {
+			log.Fatal("❌ Failed to load migrations:", err)
+		}
+		count, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatal("❌ Failed to apply migrations:", err)
+		}
+		log.Printf("✅ Database schema up to date, %d migration(s) applied\n", count)
+	}
+
+	enginePool := engine.NewPool(engine.PoolConfig{
+		Path:                cfg.StockfishPath,
+		Size:                cfg.EnginePoolSize,
+		AcquireTimeout:      cfg.EngineAcquireTimeout,
+		HealthCheckInterval: cfg.EngineHealthCheckInterval,
+	})
+	defer enginePool.Close()
+
+	if err := enginePool.Warm(); err != nil {
+		log.Println("⚠️ Failed to warm up engine pool:", err)
+	} else {
+		log.Println("✅ Engine pool warmed up")
+	}
+
+	botLevels, err := difficulty.Load(cfg.BotLevelsFile)
+	if err != nil {
+		log.Fatal("❌ Failed to load bot levels:", err)
+	}
+
+	llmClient, err := llm.NewGemini(context.Background(), llm.GeminiConfig{
+		APIKey:       cfg.GeminiAPIKey,
+		DefaultModel: cfg.LLMDefaultModel,
+		Models: map[llm.Task]string{
+			llm.TaskChat:        cfg.LLMChatModel,
+			llm.TaskMoveParsing: cfg.LLMMoveParsingModel,
+			llm.TaskHint:        cfg.LLMHintModel,
+			llm.TaskVision:      cfg.LLMVisionModel,
+		},
+		Timeout:    cfg.LLMTimeout,
+		MaxRetries: cfg.LLMMaxRetries,
+	})
+	if err != nil {
+		log.Fatal("❌ Failed to create Gemini client:", err)
+	}
+	defer llmClient.Close()
+
 	gameplayRepo := repo.NewGameplayRepo(database)
 	analysisRepo := repo.NewAnalysisRepo(database)
 	userRepo := repo.NewUserRepo(database)
+	idempotencyRepo := repo.NewIdempotencyRepo(database)
 
-	analysisService := services.NewAnalysisService(analysisRepo)
-	gameplayService := services.NewGameplayService(gameplayRepo, analysisService)
+	analysisService := services.NewAnalysisService(analysisRepo, enginePool, llmClient, botLevels)
+	voiceChoices := pending.NewStore[models.PendingChoice](cfg.VoiceDialogueTTL)
+
+	gameplayService := services.NewGameplayService(gameplayRepo, analysisService, llmClient, voiceChoices, botLevels)
 	userService := services.NewUserService(userRepo)
 
 	gin.SetMode(cfg.GinMode)
@@ -55,13 +124,13 @@ func main() {
 	})
 
 	chatApi := r.Group("/api/chat")
-	routes.ChatRoutes(chatApi, cfg)
+	routes.ChatRoutes(chatApi, cfg, llmClient)
 
 	chessApi := r.Group("/api/chess")
-	routes.ChessRoutes(chessApi, cfg)
+	routes.ChessRoutes(chessApi, cfg, llmClient, voiceChoices)
 
 	gameplayApi := r.Group("/api/gameplay")
-	routes.GameplayRoutes(gameplayApi, cfg, gameplayService)
+	routes.GameplayRoutes(gameplayApi, cfg, gameplayService, middleware.Idempotency(idempotencyRepo, cfg.IdempotencyKeyTTL))
 
 	analysisApi := r.Group("/api/analysis")
 	routes.AnalysisRoutes(analysisApi, cfg, analysisService)
@@ -75,3 +144,21 @@ func main() {
 		log.Fatal("❌ Failed to start server:", err)
 	}
 }
+
+// runMigrations carries out the migrate subcommand, e.g. "main migrate up".
+func runMigrations(args []string) {
+	database, err := db.New()
+	if err != nil {
+		log.Fatal("❌ Failed to connect to database:", err)
+	}
+	defer database.Close()
+
+	migrator, err := migrate.New(database, schema.Migrations)
+	if err != nil {
+		log.Fatal("❌ Failed to load migrations:", err)
+	}
+
+	if err := migrate.Run(context.Background(), migrator, args, os.Stdout); err != nil {
+		log.Fatal("❌ Migration failed:", err)
+	}
+}
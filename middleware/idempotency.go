@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"samsungvoicebe/models"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	defaultIdempotencyKeyTTL  = 24 * time.Hour
+	idempotencyInFlightWindow = 2 * time.Minute
+)
+
+// IdempotencyStore keeps the reserved keys and their responses; in
+// production it is repo.IdempotencyRepo.
+type IdempotencyStore interface {
+	Reserve(record models.IdempotencyRecord, staleBefore time.Time) (bool, models.IdempotencyRecord, error)
+	Complete(key, scope string, statusCode int, response string) error
+	Release(key, scope string) error
+}
+
+// Idempotency makes retried requests safe. The first request with a given
+// Idempotency-Key header is handled as usual and its response is kept for
+// ttl; a retry with the same key and body gets that response back, marked
+// with the Idempotent-Replayed header, instead of being handled again.
+// Reusing a key for a different body is rejected, as is a retry while the
+// first request is still being handled. Server errors are not kept, so
+// they can be retried. Requests without the header are passed through.
+func Idempotency(keys IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is longer than 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			log.Println("Idempotency-ReadAll", err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		now := time.Now()
+		record := models.IdempotencyRecord{
+			Key:         key,
+			Scope:       c.Request.Method + " " + c.Request.URL.Path,
+			RequestHash: hex.EncodeToString(hash[:]),
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		reserved, stored, err := keys.Reserve(record, now.Add(-idempotencyInFlightWindow))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			log.Println("Idempotency-Reserve", err)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case stored.RequestHash != record.RequestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			case stored.StatusCode == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still being processed"})
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(stored.StatusCode, "application/json; charset=utf-8", []byte(stored.Response))
+				c.Abort()
+			}
+			return
+		}
+
+		// A panicking handler sends no response to keep, so the key is
+		// released for a retry instead of being held as in flight.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if err := keys.Release(record.Key, record.Scope); err != nil {
+					log.Println("Idempotency-Release", err)
+				}
+				panic(recovered)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			if err := keys.Release(record.Key, record.Scope); err != nil {
+				log.Println("Idempotency-Release", err)
+			}
+			return
+		}
+		if err := keys.Complete(record.Key, record.Scope, writer.Status(), writer.body.String()); err != nil {
+			log.Println("Idempotency-Complete", err)
+		}
+	}
+}
+
+// recordingWriter keeps a copy of the response body as it is written.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
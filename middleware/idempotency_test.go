@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"samsungvoicebe/models"
+)
+
+// memoryStore is an IdempotencyStore following the rules of the SQL one.
+type memoryStore struct {
+	mu       sync.Mutex
+	records  map[string]models.IdempotencyRecord
+	reserved map[string]time.Time
+	err      error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: map[string]models.IdempotencyRecord{}, reserved: map[string]time.Time{}}
+}
+
+func (m *memoryStore) Reserve(record models.IdempotencyRecord, staleBefore time.Time) (bool, models.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return false, models.IdempotencyRecord{}, m.err
+	}
+
+	id := record.Key + " " + record.Scope
+	stored, ok := m.records[id]
+	if ok && (stored.StatusCode != 0 || !m.reserved[id].Before(staleBefore)) {
+		return false, stored, nil
+	}
+	m.records[id] = record
+	m.reserved[id] = time.Now()
+	return true, models.IdempotencyRecord{}, nil
+}
+
+func (m *memoryStore) Complete(key, scope string, statusCode int, response string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record := m.records[key+" "+scope]
+	record.StatusCode, record.Response = statusCode, response
+	m.records[key+" "+scope] = record
+	return nil
+}
+
+func (m *memoryStore) Release(key, scope string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.records[key+" "+scope].StatusCode == 0 {
+		delete(m.records, key+" "+scope)
+	}
+	return nil
+}
+
+// idempotentRouter echoes the request body back with status, counting how
+// often the handler ran.
+func idempotentRouter(store IdempotencyStore, status int) (*gin.Engine, *int) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+
+	router := gin.New()
+	handler := func(c *gin.Context) {
+		calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		c.JSON(status, gin.H{"body": string(body), "calls": calls})
+	}
+	router.POST("/games", Idempotency(store, time.Hour), handler)
+	router.POST("/games/import", Idempotency(store, time.Hour), handler)
+	return router, &calls
+}
+
+func send(router *gin.Engine, path, key, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		request.Header.Set(IdempotencyKeyHeader, key)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotency(t *testing.T) {
+	type step struct {
+		path, key, body string
+		wantCode        int
+		wantReplayed    bool
+	}
+
+	tests := []struct {
+		name      string
+		status    int
+		steps     []step
+		wantCalls int
+	}{
+		{
+			name:   "no key",
+			status: http.StatusCreated,
+			steps: []step{
+				{path: "/games", body: `{}`, wantCode: http.StatusCreated},
+				{path: "/games", body: `{}`, wantCode: http.StatusCreated},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "retry is replayed",
+			status: http.StatusCreated,
+			steps: []step{
+				{path: "/games", key: "k1", body: `{"color":"white"}`, wantCode: http.StatusCreated},
+				{path: "/games", key: "k1", body: `{"color":"white"}`, wantCode: http.StatusCreated, wantReplayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "client errors are replayed",
+			status: http.StatusBadRequest,
+			steps: []step{
+				{path: "/games", key: "k1", body: `{}`, wantCode: http.StatusBadRequest},
+				{path: "/games", key: "k1", body: `{}`, wantCode: http.StatusBadRequest, wantReplayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "server errors are retried",
+			status: http.StatusInternalServerError,
+			steps: []step{
+				{path: "/games", key: "k1", body: `{}`, wantCode: http.StatusInternalServerError},
+				{path: "/games", key: "k1", body: `{}`, wantCode: http.StatusInternalServerError},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "key reused for another body",
+			status: http.StatusCreated,
+			steps: []step{
+				{path: "/games", key: "k1", body: `{"color":"white"}`, wantCode: http.StatusCreated},
+				{path: "/games", key: "k1", body: `{"color":"black"}`, wantCode: http.StatusUnprocessableEntity},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "keys are scoped to the route",
+			status: http.StatusCreated,
+			steps: []step{
+				{path: "/games", key: "k1", body: `{}`, wantCode: http.StatusCreated},
+				{path: "/games/import", key: "k1", body: `{}`, wantCode: http.StatusCreated},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "key too long",
+			status: http.StatusCreated,
+			steps: []step{
+				{path: "/games", key: strings.Repeat("k", maxIdempotencyKeyLength+1), body: `{}`, wantCode: http.StatusBadRequest},
+			},
+			wantCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, calls := idempotentRouter(newMemoryStore(), tt.status)
+
+			var first string
+			for i, step := range tt.steps {
+				recorder := send(router, step.path, step.key, step.body)
+
+				if recorder.Code != step.wantCode {
+					t.Fatalf("request %d: code = %d, want %d (%s)", i+1, recorder.Code, step.wantCode, recorder.Body.String())
+				}
+				replayed := recorder.Header().Get(IdempotentReplayedHeader) == "true"
+				if replayed != step.wantReplayed {
+					t.Errorf("request %d: replayed = %v, want %v", i+1, replayed, step.wantReplayed)
+				}
+				if replayed && recorder.Body.String() != first {
+					t.Errorf("request %d: replayed %s, want the first response %s", i+1, recorder.Body.String(), first)
+				}
+				if i == 0 {
+					first = recorder.Body.String()
+				}
+			}
+
+			if *calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	tests := []struct {
+		name       string
+		reservedAt time.Time
+		wantCode   int
+		wantCalls  int
+	}{
+		{name: "still being handled", reservedAt: time.Now(), wantCode: http.StatusConflict, wantCalls: 0},
+		{name: "lost request is taken over", reservedAt: time.Now().Add(-2 * idempotencyInFlightWindow), wantCode: http.StatusCreated, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			router, calls := idempotentRouter(store, http.StatusCreated)
+
+			// Reserve the key the way an earlier request with the same body would.
+			if recorder := send(router, "/games", "k1", `{}`); recorder.Code != http.StatusCreated {
+				t.Fatalf("first request: code = %d", recorder.Code)
+			}
+			record := store.records["k1 POST /games"]
+			record.StatusCode, record.Response = 0, ""
+			store.records["k1 POST /games"] = record
+			store.reserved["k1 POST /games"] = tt.reservedAt
+			*calls = 0
+
+			recorder := send(router, "/games", "k1", `{}`)
+			if recorder.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d (%s)", recorder.Code, tt.wantCode, recorder.Body.String())
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyStoreError(t *testing.T) {
+	store := newMemoryStore()
+	store.err = errors.New("connection refused")
+	router, calls := idempotentRouter(store, http.StatusCreated)
+
+	if recorder := send(router, "/games", "k1", `{}`); recorder.Code != http.StatusInternalServerError {
+		t.Errorf("code = %d, want 500", recorder.Code)
+	}
+	if *calls != 0 {
+		t.Errorf("handler ran %d times without a reserved key", *calls)
+	}
+}
+
+func TestIdempotencyPanicReleasesKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newMemoryStore()
+	calls := 0
+
+	router := gin.New()
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.POST("/games", Idempotency(store, time.Hour), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("handler bug")
+		}
+		c.JSON(http.StatusCreated, gin.H{"calls": calls})
+	})
+
+	if recorder := send(router, "/games", "k1", `{}`); recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking request: code = %d, want 500", recorder.Code)
+	}
+	if _, ok := store.records["k1 POST /games"]; ok {
+		t.Fatal("key is still held after the handler panicked")
+	}
+	if recorder := send(router, "/games", "k1", `{}`); recorder.Code != http.StatusCreated {
+		t.Errorf("retry: code = %d, want 201 (%s)", recorder.Code, recorder.Body.String())
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Usage describes the migrate subcommand.
+const Usage = `usage: migrate <command>
+
+commands:
+  up                apply every pending migration
+  down [steps]      revert the last applied migration, or the last steps ones
+  to VERSION        migrate up or down to VERSION; 0 reverts everything
+  status            list the migrations and whether they are applied
+  baseline VERSION  mark migrations up to VERSION as applied without running
+                    them, for databases set up before migrations were tracked
+`
+
+// Run carries out the migrate subcommand given by args and reports to out.
+func Run(ctx context.Context, m *Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate-Run: missing command\n%s", Usage)
+	}
+
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		count, err := m.Up(ctx)
+		return report(out, "applied %d migration(s)\n", count, err)
+
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("migrate-Run: steps must be a positive number, got %q", args[1])
+			}
+		}
+		count, err := m.Down(ctx, steps)
+		return report(out, "reverted %d migration(s)\n", count, err)
+
+	case (command == "to" || command == "baseline") && len(args) == 2:
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("migrate-Run: version must be a migration timestamp, got %q", args[1])
+		}
+		if command == "baseline" {
+			count, err := m.Baseline(ctx, version)
+			return report(out, "marked %d migration(s) as applied\n", count, err)
+		}
+		count, err := m.To(ctx, version)
+		return report(out, "applied or reverted %d migration(s)\n", count, err)
+
+	case command == "status" && len(args) == 1:
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return writer.Flush()
+
+	default:
+		return fmt.Errorf("migrate-Run: unknown command %q\n%s", args, Usage)
+	}
+}
+
+// report tells out how many migrations a command got through, which is
+// worth knowing even when it failed part way, and passes err on.
+func report(out io.Writer, format string, count int, err error) error {
+	if err == nil || count > 0 {
+		fmt.Fprintf(out, format, count)
+	}
+	return err
+}
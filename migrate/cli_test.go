@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunArguments(t *testing.T) {
+	// Every case fails before the database is touched, so the migrator
+	// needs none.
+	m := &Migrator{migrations: []Migration{{Version: 20250906135602, Name: "create_users"}}}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+		wantMsg string
+	}{
+		{name: "no command", args: nil, wantMsg: "missing command"},
+		{name: "unknown command", args: []string{"sideways"}, wantMsg: "unknown command"},
+		{name: "up with an argument", args: []string{"up", "2"}, wantMsg: "unknown command"},
+		{name: "status with an argument", args: []string{"status", "all"}, wantMsg: "unknown command"},
+		{name: "down with two arguments", args: []string{"down", "1", "2"}, wantMsg: "unknown command"},
+		{name: "down with zero steps", args: []string{"down", "0"}, wantMsg: "steps must be a positive number"},
+		{name: "down with a word", args: []string{"down", "all"}, wantMsg: "steps must be a positive number"},
+		{name: "to without a version", args: []string{"to"}, wantMsg: "unknown command"},
+		{name: "to with a negative version", args: []string{"to", "-1"}, wantMsg: "version must be a migration timestamp"},
+		{name: "baseline with a word", args: []string{"baseline", "latest"}, wantMsg: "version must be a migration timestamp"},
+		{name: "to an unknown version", args: []string{"to", "20250906135603"}, wantErr: ErrInvalidMigrations},
+		{name: "baseline an unknown version", args: []string{"baseline", "0"}, wantErr: ErrInvalidMigrations},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Run(context.Background(), m, tt.args, &out)
+			if err == nil {
+				t.Fatalf("Run(%q) succeeded, want an error", tt.args)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run(%q) error = %v, want %v", tt.args, err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("Run(%q) error = %v, want it to mention %q", tt.args, err, tt.wantMsg)
+			}
+			if out.Len() > 0 {
+				t.Errorf("Run(%q) reported %q for a failed command", tt.args, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+// Package migrate applies and rolls back the SQL migrations embedded in the
+// binary, keeping track of the applied ones in the schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"samsungvoicebe/pg_sql"
+)
+
+// lockID is the Postgres advisory lock held while migrating, so instances
+// starting together apply each migration once.
+const lockID = 4720250906135324
+
+var ErrInvalidMigrations = errors.New("invalid migrations")
+
+var fileName = regexp.MustCompile(`^(\d{14})_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one schema change with the SQL to apply and to revert it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and whether, and when, it was applied.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New loads the migrations in fsys. Every migration needs both its up and
+// its down file.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("migrate-New-load: %w", err)
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, file := range files {
+		match := fileName.FindStringSubmatch(file)
+		if match == nil {
+			return nil, fmt.Errorf("%w: %s is not named YYYYMMDDHHMMSS_name.up.sql or .down.sql", ErrInvalidMigrations, file)
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("%w: version %d is used by %s and %s", ErrInvalidMigrations, version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("%w: %d_%s needs both an up and a down file", ErrInvalidMigrations, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Latest is the version of the newest migration, or 0 without migrations.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every migration not applied yet, oldest first, and returns how
+// many it applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count, err := m.To(ctx, m.Latest())
+	if err != nil {
+		return count, fmt.Errorf("migrate-Up-To: %w", err)
+	}
+	return count, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// how many it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("migrate-Down-locked: %w", err)
+	}
+	return count, nil
+}
+
+// To applies every migration up to version and reverts every applied one
+// after it, so version 0 reverts them all. It returns how many migrations
+// it applied or reverted.
+func (m *Migrator) To(ctx context.Context, version int64) (int, error) {
+	if version != 0 && !m.known(version) {
+		return 0, fmt.Errorf("migrate-To: %w: no migration with version %d", ErrInvalidMigrations, version)
+	}
+
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+				continue
+			}
+			if err := revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			if err := apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("migrate-To-locked: %w", err)
+	}
+	return count, nil
+}
+
+// Baseline records every migration up to version as applied without
+// running it, for databases whose schema was set up by hand.
+func (m *Migrator) Baseline(ctx context.Context, version int64) (int, error) {
+	if !m.known(version) {
+		return 0, fmt.Errorf("migrate-Baseline: %w: no migration with version %d", ErrInvalidMigrations, version)
+	}
+
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			_, err := conn.ExecContext(ctx, pg_sql.RecordMigration,
+				migration.Version, migration.Name)
+			if err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("migrate-Baseline-locked: %w", err)
+	}
+	return count, nil
+}
+
+// Status lists every migration, oldest first, with whether it is applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			appliedAt, ok := applied[migration.Version]
+			statuses = append(statuses, Status{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Applied:   ok,
+				AppliedAt: appliedAt,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("migrate-Status-locked: %w", err)
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// locked runs fn on one connection while holding the migration advisory
+// lock, after making sure the schema_migrations table exists.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, pg_sql.LockMigrations, lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), pg_sql.UnlockMigrations, lockID)
+
+	if _, err := conn.ExecContext(ctx, pg_sql.CreateSchemaMigrations); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, pg_sql.GetAppliedMigrations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// apply runs migration's up SQL and records it, in one transaction.
+func apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return fmt.Errorf("applying %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	_, err = tx.ExecContext(ctx, pg_sql.RecordMigration,
+		migration.Version, migration.Name)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// revert runs migration's down SQL and forgets it, in one transaction.
+func revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+		return fmt.Errorf("reverting %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	_, err = tx.ExecContext(ctx, pg_sql.ForgetMigration, migration.Version)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
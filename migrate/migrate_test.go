@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"samsungvoicebe/schema"
+)
+
+func TestLoad(t *testing.T) {
+	file := func(content string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(content)}
+	}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr bool
+	}{
+		{name: "no migrations", fsys: fstest.MapFS{}, want: []Migration{}},
+		{
+			name: "pairs sorted by version",
+			fsys: fstest.MapFS{
+				"20250911150538_drop_fen.up.sql":       file("ALTER TABLE games DROP COLUMN fen;"),
+				"20250911150538_drop_fen.down.sql":     file("ALTER TABLE games ADD COLUMN fen text;"),
+				"20250906135602_create_users.up.sql":   file("CREATE TABLE users ();"),
+				"20250906135602_create_users.down.sql": file("DROP TABLE users;"),
+				"README.md":                            file("not a migration"),
+			},
+			want: []Migration{
+				{Version: 20250906135602, Name: "create_users", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"},
+				{Version: 20250911150538, Name: "drop_fen", Up: "ALTER TABLE games DROP COLUMN fen;", Down: "ALTER TABLE games ADD COLUMN fen text;"},
+			},
+		},
+		{
+			name:    "missing down file",
+			fsys:    fstest.MapFS{"20250906135602_create_users.up.sql": file("CREATE TABLE users ();")},
+			wantErr: true,
+		},
+		{
+			name:    "missing up file",
+			fsys:    fstest.MapFS{"20250906135602_create_users.down.sql": file("DROP TABLE users;")},
+			wantErr: true,
+		},
+		{
+			name: "empty down file",
+			fsys: fstest.MapFS{
+				"20250906135602_create_users.up.sql":   file("CREATE TABLE users ();"),
+				"20250906135602_create_users.down.sql": file(""),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "short version",
+			fsys:    fstest.MapFS{"202509061356_create_users.up.sql": file("CREATE TABLE users ();")},
+			wantErr: true,
+		},
+		{
+			name:    "no direction",
+			fsys:    fstest.MapFS{"20250906135602_create_users.sql": file("CREATE TABLE users ();")},
+			wantErr: true,
+		},
+		{
+			name: "version used twice",
+			fsys: fstest.MapFS{
+				"20250906135602_create_users.up.sql":   file("CREATE TABLE users ();"),
+				"20250906135602_create_users.down.sql": file("DROP TABLE users;"),
+				"20250906135602_create_games.up.sql":   file("CREATE TABLE games ();"),
+				"20250906135602_create_games.down.sql": file("DROP TABLE games;"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := load(tt.fsys)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMigrations) {
+					t.Fatalf("load() error = %v, want ErrInvalidMigrations", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	migrations, err := load(schema.Migrations)
+	if err != nil {
+		t.Fatalf("load(schema.Migrations): %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("load(schema.Migrations) found no migrations")
+	}
+}
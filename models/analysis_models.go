@@ -1,33 +1,36 @@
 package models
 
 type Move struct {
-	Move string `db:"move"`
-	Fen  string `db:"fen"`
+	Move      string `db:"move"`
+	Fen       string `db:"fen"`
+	MoveOrder int    `db:"move_order"`
+	CreatedAt string `db:"created_at"`
 }
 
 type MoveAnalysis struct {
-	Move     string `json:"move"`
-	Fen      string `json:"fen"`
-	BestMove string `json:"best_move"`
+	Move       string      `json:"move"`
+	Fen        string      `json:"fen"`
+	BestMove   string      `json:"best_move"`
+	Evaluation *Evaluation `json:"evaluation,omitempty"`
 }
 
 type Game struct {
-	GameID     string `db:"game_id"`
-	Date       string `db:"date"`
-	MoveAmount int    `db:"move_amount"`
+	GameID      string `db:"game_id"`
+	Date        string `db:"date"`
+	MoveAmount  int    `db:"move_amount"`
+	Result      string `db:"result"`
+	Termination string `db:"termination"`
 }
 
+// StockfishAnalysisResult is the bot's move and the FEN after it. Score is
+// the engine's evaluation in centipawns for the side that moved, when the
+// engine reported one.
 type StockfishAnalysisResult struct {
 	Fen      string
 	BestMove string
+	Score    *int
 }
 
-const (
-	BotLevelEasy   = 2
-	BotLevelMedium = 5
-	BotLevelhard   = 10
-)
-
 const GetFenFromPicturePrompt = `
 	You are an OCR (Optical Character Recognition) expert. Your task is to extract the Forsyth-Edwards Notation (FEN)
 	from the given picture. The FEN is a standard notation for describing 
@@ -69,4 +72,65 @@ const GetFenFromPicturePrompt = `
 `
 const InvalidImage = "InvalidImage"
 
-const GeminiModel = "gemini-2.5-pro"
+const ReportDepth = 12
+
+// HintDepth is how deep hints are searched; it matches ReportDepth so hints
+// and reports share cached analyses.
+const HintDepth = ReportDepth
+
+// MoveAnalysisDepth is how deep a single stored move is analysed.
+const MoveAnalysisDepth = 10
+
+const (
+	MoveClassBest       = "best"
+	MoveClassGood       = "good"
+	MoveClassInaccuracy = "inaccuracy"
+	MoveClassMistake    = "mistake"
+	MoveClassBlunder    = "blunder"
+)
+
+// Evaluation is an engine score from white's point of view. Mate is set when
+// the engine sees a forced mate (positive: white mates) and CP then holds
+// ±MateScore so evaluations stay comparable.
+type Evaluation struct {
+	CP       int      `json:"cp"`
+	Mate     *int     `json:"mate,omitempty"`
+	BestMove string   `json:"best_move,omitempty"`
+	PV       []string `json:"pv,omitempty"`
+}
+
+const MateScore = 10000
+
+type MoveReport struct {
+	MoveOrder      int        `json:"move_order"`
+	Move           string     `json:"move"`
+	San            string     `json:"san"`
+	Color          string     `json:"color"`
+	Fen            string     `json:"fen"`
+	EvalBefore     Evaluation `json:"eval_before"`
+	EvalAfter      Evaluation `json:"eval_after"`
+	BestMove       string     `json:"best_move"`
+	CentipawnLoss  int        `json:"centipawn_loss"`
+	Classification string     `json:"classification"`
+	Accuracy       float64    `json:"accuracy"`
+}
+
+type SideSummary struct {
+	Accuracy             float64 `json:"accuracy"`
+	AverageCentipawnLoss float64 `json:"average_centipawn_loss"`
+	Best                 int     `json:"best"`
+	Good                 int     `json:"good"`
+	Inaccuracies         int     `json:"inaccuracies"`
+	Mistakes             int     `json:"mistakes"`
+	Blunders             int     `json:"blunders"`
+}
+
+type GameReport struct {
+	GameID      string       `json:"game_id"`
+	StartingFen string       `json:"starting_fen"`
+	Depth       int          `json:"depth"`
+	Takebacks   int          `json:"takebacks"`
+	Moves       []MoveReport `json:"moves"`
+	White       SideSummary  `json:"white"`
+	Black       SideSummary  `json:"black"`
+}
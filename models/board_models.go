@@ -0,0 +1,81 @@
+package models
+
+// BoardDescriptionRequest describes either a FEN or the current position of
+// a stored game. Square, Rank and Piece narrow the answer to a question such
+// as "what is on d4?", "what is on the fourth rank?" or "where are my
+// knights?"; Color picks whose pieces a piece query is about and defaults to
+// the side to move.
+type BoardDescriptionRequest struct {
+	Fen    string `json:"fen" binding:"required_without=GameID"`
+	GameID string `json:"game_id" binding:"omitempty,uuid"`
+	Square string `json:"square"`
+	Rank   int    `json:"rank" binding:"omitempty,min=1,max=8"`
+	Piece  string `json:"piece"`
+	Color  string `json:"color" binding:"omitempty,oneof=white black"`
+	NarrationOptions
+}
+
+type PieceGroup struct {
+	Piece   string   `json:"piece"`
+	Squares []string `json:"squares"`
+}
+
+type SideDescription struct {
+	Pieces             []PieceGroup `json:"pieces"`
+	Material           int          `json:"material"`
+	CanCastleKingside  bool         `json:"can_castle_kingside"`
+	CanCastleQueenside bool         `json:"can_castle_queenside"`
+}
+
+type OccupiedSquare struct {
+	Square string `json:"square"`
+	Piece  string `json:"piece,omitempty"`
+	Color  string `json:"color,omitempty"`
+}
+
+type BoardQueryResult struct {
+	Squares []OccupiedSquare `json:"squares"`
+	Spoken  string           `json:"spoken"`
+}
+
+// BoardDescription is a position laid out for players who cannot see the
+// board. MaterialBalance is white's material minus black's in pawns.
+type BoardDescription struct {
+	Fen             string            `json:"fen"`
+	Turn            string            `json:"turn"`
+	Status          string            `json:"status"`
+	InCheck         bool              `json:"in_check"`
+	White           SideDescription   `json:"white"`
+	Black           SideDescription   `json:"black"`
+	MaterialBalance int               `json:"material_balance"`
+	EnPassant       string            `json:"en_passant,omitempty"`
+	Query           *BoardQueryResult `json:"query,omitempty"`
+	Spoken          string            `json:"spoken"`
+}
+
+// WarningsRequest asks for the threats facing the side to move in a FEN or
+// in the current position of a stored game.
+type WarningsRequest struct {
+	Fen    string `json:"fen" binding:"required_without=GameID"`
+	GameID string `json:"game_id" binding:"omitempty,uuid"`
+	NarrationOptions
+}
+
+// Warning is one danger to the side to move: check, hanging, underdefended,
+// pinned or mate_threat. Attackers are the opponent's pieces involved (the
+// pinning piece for a pin) and Move the opponent's mating move in UCI.
+type Warning struct {
+	Type      string   `json:"type"`
+	Square    string   `json:"square"`
+	Piece     string   `json:"piece"`
+	Attackers []string `json:"attackers,omitempty"`
+	Defenders []string `json:"defenders,omitempty"`
+	Move      string   `json:"move,omitempty"`
+	Spoken    string   `json:"spoken"`
+}
+
+type PositionWarnings struct {
+	Fen      string    `json:"fen"`
+	Turn     string    `json:"turn"`
+	Warnings []Warning `json:"warnings"`
+}
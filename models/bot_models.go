@@ -0,0 +1,107 @@
+package models
+
+// BotProfile is how strong the bot plays. Elo is the approximate rating the
+// profile plays at and is what clients show. SkillLevel (0-20) is passed to
+// Stockfish as "Skill Level"; a non-zero UCIElo turns on UCI_LimitStrength
+// with that rating instead. Depth, MoveTimeMs and Nodes bound each search and
+// any of them may be zero.
+type BotProfile struct {
+	Name       string `json:"name"`
+	Label      string `json:"label"`
+	Elo        int    `json:"elo"`
+	SkillLevel int    `json:"skill_level"`
+	UCIElo     int    `json:"uci_elo,omitempty"`
+	Depth      int    `json:"depth,omitempty"`
+	MoveTimeMs int    `json:"movetime_ms,omitempty"`
+	Nodes      int    `json:"nodes,omitempty"`
+}
+
+// StyleWeights are centipawn bonuses a personality gives a move for what it
+// does: Material per pawn of material captured, PawnGrabs for taking a pawn,
+// Checks for giving check, KingAttack per extra square next to the enemy
+// king it attacks, Center for landing on d4, e4, d5 or e5, Threats per enemy
+// piece it leaves in danger, Safety per own piece it keeps out of danger and
+// Trades for swapping equal pieces.
+type StyleWeights struct {
+	Material   int `json:"material,omitempty"`
+	PawnGrabs  int `json:"pawn_grabs,omitempty"`
+	Checks     int `json:"checks,omitempty"`
+	KingAttack int `json:"king_attack,omitempty"`
+	Center     int `json:"center,omitempty"`
+	Threats    int `json:"threats,omitempty"`
+	Safety     int `json:"safety,omitempty"`
+	Trades     int `json:"trades,omitempty"`
+}
+
+// Personality is a playing style. Among the candidate moves scoring within
+// EvalWindow centipawns of the engine's choice, the bot plays the one whose
+// style bonus minus what it gives away is highest.
+type Personality struct {
+	Name        string       `json:"name"`
+	Label       string       `json:"label"`
+	Description string       `json:"description"`
+	EvalWindow  int          `json:"eval_window"`
+	Weights     StyleWeights `json:"weights"`
+}
+
+const (
+	PersonalityAttacker    = "attacker"
+	PersonalityDefender    = "defender"
+	PersonalityPawnGrabber = "pawn_grabber"
+	PersonalityTrickster   = "trickster"
+)
+
+var Personalities = []Personality{
+	{
+		Name:        PersonalityAttacker,
+		Label:       "Aggressive attacker",
+		Description: "Goes after your king with checks and threats.",
+		EvalWindow:  60,
+		Weights:     StyleWeights{Checks: 40, KingAttack: 12, Threats: 15, Center: 5, Material: 5},
+	},
+	{
+		Name:        PersonalityDefender,
+		Label:       "Solid defender",
+		Description: "Keeps its pieces protected and trades when it can.",
+		EvalWindow:  40,
+		Weights:     StyleWeights{Safety: 30, Trades: 20, Center: 10},
+	},
+	{
+		Name:        PersonalityPawnGrabber,
+		Label:       "Pawn grabber",
+		Description: "Takes every pawn it can get away with.",
+		EvalWindow:  80,
+		Weights:     StyleWeights{PawnGrabs: 60, Material: 15},
+	},
+	{
+		Name:        PersonalityTrickster,
+		Label:       "Coffeehouse trickster",
+		Description: "Sets traps and cheap threats, hoping you miss them.",
+		EvalWindow:  120,
+		Weights:     StyleWeights{Threats: 35, Checks: 25, KingAttack: 8},
+	},
+}
+
+// GetPersonality finds a personality by name. The empty name is the plain
+// engine with no style and is always found.
+func GetPersonality(name string) (Personality, bool) {
+	if name == "" {
+		return Personality{}, true
+	}
+	for _, personality := range Personalities {
+		if personality.Name == name {
+			return personality, true
+		}
+	}
+	return Personality{}, false
+}
+
+// BotSettings pick how the bot plays: at BotElo when it is set and at the
+// named BotLevel otherwise, in the style of Personality when one is given.
+// Moves in a stored game fall back to the settings the game was created
+// with.
+type BotSettings struct {
+	BotLevel    string `json:"bot_level"`
+	BotElo      int    `json:"bot_elo" binding:"omitempty,min=100,max=3500"`
+	Personality string `json:"personality" binding:"omitempty,oneof=attacker defender pawn_grabber trickster"`
+}
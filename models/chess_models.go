@@ -5,6 +5,11 @@ type ChessRequest struct {
 	Fen     string `json:"fen" binding:"required"`
 	Type    string `json:"type" binding:"required,oneof=black white"`
 	Mode    string `json:"mode" binding:"required,oneof=easy medium hard"`
+	// Personality gives the AI a playing style; see Personalities.
+	Personality string `json:"personality" binding:"omitempty,oneof=attacker defender pawn_grabber trickster"`
+	// IncludeWarnings lists the threats the player faces after the AI moves.
+	IncludeWarnings bool `json:"include_warnings"`
+	NarrationOptions
 }
 
 type ChessResponse struct {
@@ -14,6 +19,11 @@ type ChessResponse struct {
 	Error     string `json:"error,omitempty"`
 	IsGameEnd bool   `json:"is_game_end,omitempty"`
 	Winner    string `json:"winner,omitempty"`
+	Narration string `json:"narration,omitempty"`
+
+	Warnings       []Warning       `json:"warnings,omitempty"`
+	Disambiguation *Disambiguation `json:"disambiguation,omitempty"`
+	Confirmation   *Confirmation   `json:"confirmation,omitempty"`
 }
 
 type GeminiMoveAnalysis struct {
@@ -25,39 +35,32 @@ type GeminiMoveAnalysis struct {
 	Explanation    string `json:"explanation"`
 }
 
+// AIStrategy configures the built-in search: Depth is the number of plies
+// searched before quiescence and RandomFactor (0-1) how far below the best
+// move the bot may stray. A Personality replaces the random pick with one
+// guided by its style.
 type AIStrategy struct {
-	Depth          int
-	RandomFactor   float64
-	PreferCaptures bool
-	PreferCenter   bool
-	AvoidBlunders  bool
+	Depth        int
+	RandomFactor float64
+	Personality  Personality
 }
 
 func GetAIStrategy(mode string) AIStrategy {
 	switch mode {
 	case "easy":
 		return AIStrategy{
-			Depth:          1,
-			RandomFactor:   0.4,
-			PreferCaptures: false,
-			PreferCenter:   false,
-			AvoidBlunders:  false,
+			Depth:        1,
+			RandomFactor: 0.4,
 		}
 	case "medium":
 		return AIStrategy{
-			Depth:          2,
-			RandomFactor:   0.2,
-			PreferCaptures: true,
-			PreferCenter:   true,
-			AvoidBlunders:  true,
+			Depth:        2,
+			RandomFactor: 0.2,
 		}
 	case "hard":
 		return AIStrategy{
-			Depth:          3,
-			RandomFactor:   0.05,
-			PreferCaptures: true,
-			PreferCenter:   true,
-			AvoidBlunders:  true,
+			Depth:        3,
+			RandomFactor: 0.05,
 		}
 	default:
 		return GetAIStrategy("medium")
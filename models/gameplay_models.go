@@ -1,46 +1,287 @@
 package models
 
+// BotMove is the bot's reply. Outcome is set once the game is over, in which
+// case Move is empty when the player's own move ended it. DrawOffered means
+// the bot offers a draw the player may accept until their next move.
 type BotMove struct {
-	Move string `json:"bot_move"`
-	Fen  string `json:"fen"`
+	Move        string       `json:"bot_move"`
+	Fen         string       `json:"fen"`
+	Narration   string       `json:"narration,omitempty"`
+	Warnings    []Warning    `json:"warnings,omitempty"`
+	Outcome     *GameOutcome `json:"outcome,omitempty"`
+	DrawOffered bool         `json:"draw_offered,omitempty"`
 }
 
+const (
+	ResultWhiteWins  = "1-0"
+	ResultBlackWins  = "0-1"
+	ResultDraw       = "1/2-1/2"
+	ResultInProgress = "*"
+)
+
+const (
+	TerminationCheckmate            = "checkmate"
+	TerminationStalemate            = "stalemate"
+	TerminationThreefoldRepetition  = "threefold_repetition"
+	TerminationFivefoldRepetition   = "fivefold_repetition"
+	TerminationFiftyMoveRule        = "fifty_move_rule"
+	TerminationSeventyFiveMoveRule  = "seventy_five_move_rule"
+	TerminationInsufficientMaterial = "insufficient_material"
+	TerminationResignation          = "resignation"
+	TerminationDrawAgreement        = "draw_agreement"
+	// TerminationImported is for imported games whose result comes from the
+	// PGN without the final position showing why.
+	TerminationImported = "imported"
+)
+
+// GameOutcome is how a game ended. Winner is empty for draws.
+type GameOutcome struct {
+	Result      string `json:"result"`
+	Termination string `json:"termination"`
+	Winner      string `json:"winner,omitempty"`
+}
+
+// GameState is everything needed to resume a stored game on any device.
+type GameState struct {
+	GameID        string       `json:"game_id"`
+	CreatedAt     string       `json:"created_at"`
+	Color         string       `json:"color"`
+	BotLevel      string       `json:"bot_level,omitempty"`
+	BotElo        int          `json:"bot_elo,omitempty"`
+	Personality   string       `json:"personality,omitempty"`
+	StartingFen   string       `json:"starting_fen"`
+	TimeControl   string       `json:"time_control,omitempty"`
+	Fen           string       `json:"fen"`
+	Turn          string       `json:"turn"`
+	Status        string       `json:"status"`
+	InCheck       bool         `json:"in_check"`
+	Moves         []GameMove   `json:"moves"`
+	LastMove      *GameMove    `json:"last_move,omitempty"`
+	Outcome       *GameOutcome `json:"outcome,omitempty"`
+	DrawOfferedBy string       `json:"draw_offered_by,omitempty"`
+	Clock         *GameClock   `json:"clock,omitempty"`
+}
+
+const (
+	GameStatusInProgress = "in_progress"
+	GameStatusFinished   = "finished"
+)
+
+// GameMove is one stored move in UCI and SAN with the FEN it led to.
+type GameMove struct {
+	MoveOrder int    `json:"move_order"`
+	Move      string `json:"move"`
+	San       string `json:"san"`
+	Color     string `json:"color"`
+	Fen       string `json:"fen"`
+}
+
+// GameClock is each side's remaining time under the game's time control,
+// worked out from when the moves were stored. Running is the side whose
+// clock is ticking, empty once the game is over.
+type GameClock struct {
+	WhiteMs int64  `json:"white_ms"`
+	BlackMs int64  `json:"black_ms"`
+	Running string `json:"running,omitempty"`
+}
+
+// UnfinishedGame is a game in progress in a user's list of games to resume.
+type UnfinishedGame struct {
+	GameID       string `json:"game_id" db:"id"`
+	CreatedAt    string `json:"created_at" db:"created_at"`
+	Color        string `json:"color" db:"player_color"`
+	BotLevel     string `json:"bot_level,omitempty" db:"bot_level"`
+	BotElo       int    `json:"bot_elo,omitempty" db:"bot_elo"`
+	Personality  string `json:"personality,omitempty" db:"bot_personality"`
+	TimeControl  string `json:"time_control,omitempty" db:"time_control"`
+	MoveCount    int    `json:"move_count" db:"move_count"`
+	Fen          string `json:"fen" db:"fen"`
+	LastPlayedAt string `json:"last_played_at" db:"last_played_at"`
+}
+
+// UndoRequest asks to take back Plies half-moves; without it the player's
+// last move is taken back together with the bot's reply.
+type UndoRequest struct {
+	Plies int `json:"plies" binding:"omitempty,min=1"`
+}
+
+// Takeback is a recorded undo: the moves removed after FromMoveOrder, in
+// the order they were played, and the positions before and after.
+type Takeback struct {
+	GameID        string
+	Plies         int
+	FromMoveOrder int
+	Moves         []string
+	FenBefore     string
+	FenAfter      string
+}
+
+// UndoResult is the position after a takeback and the moves it removed.
+type UndoResult struct {
+	Fen         string   `json:"fen"`
+	Turn        string   `json:"turn"`
+	UndoneMoves []string `json:"undone_moves"`
+}
+
+// DrawOfferResult is the answer to a draw offer; Outcome is set when the
+// draw was agreed.
+type DrawOfferResult struct {
+	Accepted bool         `json:"accepted"`
+	Outcome  *GameOutcome `json:"outcome,omitempty"`
+}
+
+// PlayerMoveRequest is a player's move. IncludeWarnings asks for the threats
+// the player faces after the bot's reply.
 type PlayerMoveRequest struct {
-	Move     string `json:"move" binding:"required"`
-	Fen      string `json:"fen" binding:"required"`
-	BotLevel string `json:"bot_level" binding:"required"`
+	Move            string `json:"move" binding:"required"`
+	Fen             string `json:"fen"`
+	IncludeWarnings bool   `json:"include_warnings"`
+	BotSettings
+	NarrationOptions
 }
 
+// GameRecord is a stored game with the settings it was created with and how
+// it ended. TimeControl uses the PGN form, e.g. "300+5", and is empty for
+// untimed games. Result is "*" while the game is in progress and
+// DrawOfferedBy the color with a draw offer standing, if any.
+type GameRecord struct {
+	ID             string `db:"id"`
+	UserID         string `db:"user_id"`
+	CreatedAt      string `db:"created_at"`
+	PlayerColor    string `db:"player_color"`
+	BotLevel       string `db:"bot_level"`
+	BotElo         int    `db:"bot_elo"`
+	BotPersonality string `db:"bot_personality"`
+	StartingFen    string `db:"starting_fen"`
+	TimeControl    string `db:"time_control"`
+	Result         string `db:"result"`
+	Termination    string `db:"termination"`
+	DrawOfferedBy  string `db:"draw_offered_by"`
+}
+
+// CreateGameRequest sets up a game. Color is the player's side and defaults
+// to white; StartingFen defaults to the standard position and TimeControl
+// is "seconds" or "seconds+increment". The bot settings are used for every
+// move that does not override them.
+type CreateGameRequest struct {
+	Color       string `json:"color" binding:"omitempty,oneof=white black"`
+	StartingFen string `json:"starting_fen"`
+	TimeControl string `json:"time_control"`
+	BotSettings
+	NarrationOptions
+}
+
+// CreatedGame is a new game. When the bot has the first move, BotMove is
+// its move and Fen the position after it.
+type CreatedGame struct {
+	GameID      string   `json:"game_id"`
+	Color       string   `json:"color"`
+	StartingFen string   `json:"starting_fen"`
+	TimeControl string   `json:"time_control,omitempty"`
+	BotLevel    string   `json:"bot_level,omitempty"`
+	BotElo      int      `json:"bot_elo,omitempty"`
+	Personality string   `json:"personality,omitempty"`
+	Fen         string   `json:"fen"`
+	BotMove     *BotMove `json:"bot_move,omitempty"`
+}
+
+type ImportedGame struct {
+	Index     int    `json:"index"`
+	GameID    string `json:"game_id"`
+	MoveCount int    `json:"move_count"`
+	White     string `json:"white,omitempty"`
+	Black     string `json:"black,omitempty"`
+	Result    string `json:"result"`
+}
+
+type PGNImportError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+type PGNImportResult struct {
+	Imported []ImportedGame   `json:"imported"`
+	Errors   []PGNImportError `json:"errors"`
+}
+
+// HintRequest asks for a hint on a FEN or on the current position of a
+// stored game. Level 1 gives the general idea, 2 names the piece to move and
+// 3 the exact move; without a level, repeated requests for the same position
+// of a game go one level further each time.
 type HintRequest struct {
-	Fen string `json:"fen" binding:"required"`
+	Fen    string `json:"fen" binding:"required_without=GameID"`
+	GameID string `json:"game_id" binding:"omitempty,uuid"`
+	Level  int    `json:"level" binding:"omitempty,min=1,max=3"`
+	NarrationOptions
+}
+
+// Hint is a hint at Level. Square is the piece to move from level 2 on and
+// Move the engine's move (UCI and SAN) at level 3.
+type Hint struct {
+	Level  int    `json:"level"`
+	Hint   string `json:"hint"`
+	Square string `json:"square,omitempty"`
+	Move   string `json:"move,omitempty"`
+	San    string `json:"san,omitempty"`
 }
 
+const (
+	HintLevelIdea  = 1
+	HintLevelPiece = 2
+	HintLevelMove  = 3
+)
+
+// PlayerMoveByTranscriptionRequest carries what the player said. GameID is
+// optional and lets questions about the game's history ("what was the last
+// move?") be answered.
 type PlayerMoveByTranscriptionRequest struct {
 	Fen           string `json:"fen" binding:"required"`
 	Transcription string `json:"transcription" binding:"required"`
+	GameID        string `json:"game_id" binding:"omitempty,uuid"`
+	NarrationOptions
 }
 
+// PlayerMoveByTranscription is the reply to an utterance. Intent says what
+// the player asked for; Answer is the spoken reply to anything that is not a
+// move.
 type PlayerMoveByTranscription struct {
-	Move string `json:"move"`
-	Fen  string `json:"fen"`
+	Status         string          `json:"status"`
+	Intent         string          `json:"intent"`
+	Move           string          `json:"move,omitempty"`
+	Fen            string          `json:"fen"`
+	Narration      string          `json:"narration,omitempty"`
+	Answer         string          `json:"answer,omitempty"`
+	Disambiguation *Disambiguation `json:"disambiguation,omitempty"`
 }
 
 const HintPrompt = `
-	You are a chess tutor. Your task is to provide helpful hints to the player based on the current
-	position of the chess game. Analyze the position and suggest a move that would improve the player's chances of winning.
-	We provide the current position in Forsyth-Edwards Notation (FEN).
-	Here is the current position in FEN: %s
-    Do not blantantly give away the best move, but guide the player towards it.
-	Make sure your hint is clear and concise, focusing on strategic elements of the game.
-	Do not mention the FEN or any specific moves in your hint.
-	Do not re-explain what the FEN means. Straight to the what the hint is. 
-	Make a medium length response, around 1-3 sentences.
-	Here is an example response:
+	You are a chess tutor giving a hint to a player who may not be able to see the board.
+	A chess engine has already analysed the position, so rely on its findings instead of your own calculation.
+
+	Position (FEN): %s
+	Side to move: %s
+	Engine evaluation for the side to move: %s
+	Engine's best move: %s
+	Engine's best line: %s
+	Tactical themes of the best move: %s
+	Threats the player faces right now: %s
+
+	%s
 
-	There are pieces that can be developed to control the center of the board
-	Consider moving your knight to a position where it can attack the opponent's queen.
+	Do not mention the FEN, the engine or the evaluation numbers.
+	Do not re-explain what the position notation means. Go straight to the hint.
+	Keep it to 1-2 short sentences that read well aloud.
+	Answer in %s.
 `
 
+// HintLevelInstructions tell the tutor how much of the engine's move to give
+// away at each hint level.
+var HintLevelInstructions = map[int]string{
+	HintLevelIdea:  "Give only the general idea (for example attack, defend a piece, develop, king safety, win material). Do not name the piece to move or any square.",
+	HintLevelPiece: "Tell the player which piece to move and why, naming its square, but do not say where it should go.",
+	HintLevelMove:  "Tell the player the exact best move, spoken in words (for example \"knight from g1 to f3\"), and briefly why it works.",
+}
+
 const MoveFromDescriptionPrompt = `
 	You are a chess engine that can convert natural language descriptions of chess moves into standard algebraic notation.
 	Given the current position of a chess game in Forsyth-Edwards Notation (FEN) and a description of a desired move,
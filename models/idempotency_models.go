@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once it
+// has been handled, the response to replay when the request is retried.
+// StatusCode is 0 while the request is still being handled.
+type IdempotencyRecord struct {
+	Key         string    `db:"idempotency_key"`
+	Scope       string    `db:"scope"`
+	RequestHash string    `db:"request_hash"`
+	StatusCode  int       `db:"status_code"`
+	Response    string    `db:"response"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+const (
+	VoiceStatusMoved                  = "moved"
+	VoiceStatusDisambiguationRequired = "disambiguation_required"
+	VoiceStatusConfirmationRequired   = "confirmation_required"
+	VoiceStatusRejected               = "rejected"
+	VoiceStatusAnswered               = "answered"
+	VoiceStatusActionRequested        = "action_requested"
+)
+
+type MoveCandidate struct {
+	Move   string `json:"move"`
+	San    string `json:"san"`
+	Spoken string `json:"spoken"`
+}
+
+// Disambiguation is returned when a spoken move matches several legal moves.
+// The client reads Question aloud and posts the player's answer with Token.
+type Disambiguation struct {
+	Token      string          `json:"token"`
+	Question   string          `json:"question"`
+	Candidates []MoveCandidate `json:"candidates"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+}
+
+// NarrationOptions selects the language (en, id) and verbosity (terse,
+// normal, descriptive) of spoken text in a response.
+type NarrationOptions struct {
+	Language  string `json:"language" binding:"omitempty,oneof=en id"`
+	Verbosity string `json:"verbosity" binding:"omitempty,oneof=terse normal descriptive"`
+}
+
+// PendingChoice is what the server remembers behind a disambiguation token.
+// Narration.Language is also the language the question was asked in.
+type PendingChoice struct {
+	Fen       string
+	Moves     []string
+	Narration NarrationOptions
+}
+
+type DisambiguationRequest struct {
+	Token  string `json:"token" binding:"required"`
+	Answer string `json:"answer" binding:"required"`
+}
+
+// Confirmation is returned when a move was understood with a confidence
+// below the configured threshold. The move is only played once the player
+// confirms it with Token.
+type Confirmation struct {
+	Token      string    `json:"token"`
+	Question   string    `json:"question"`
+	Move       string    `json:"move"`
+	San        string    `json:"san"`
+	Spoken     string    `json:"spoken"`
+	Confidence int       `json:"confidence"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// PendingMove is what the server remembers behind a confirmation token.
+type PendingMove struct {
+	Fen       string
+	Move      string
+	Narration NarrationOptions
+}
+
+// ConfirmationRequest confirms or rejects a pending move, either with
+// Confirm or with the player's spoken Answer ("yes", "iya", "bukan").
+type ConfirmationRequest struct {
+	Token   string `json:"token" binding:"required"`
+	Confirm *bool  `json:"confirm"`
+	Answer  string `json:"answer"`
+}
@@ -0,0 +1,109 @@
+package narration
+
+import (
+	"github.com/notnil/chess"
+	"samsungvoicebe/models"
+	"samsungvoicebe/tactics"
+	"samsungvoicebe/voice"
+)
+
+// prompts are the spoken replies to voice requests the client has to carry
+// out itself, and to questions that cannot be answered.
+var prompts = map[string]map[string]string{
+	voice.LanguageEnglish: {
+		voice.IntentUndo:      "Take back your last move?",
+		voice.IntentResign:    "Do you want to resign?",
+		voice.IntentDrawOffer: "Offer a draw?",
+		promptNoMoves:         "No moves have been played yet.",
+		promptNoGame:          "I can only read the last move of a saved game.",
+	},
+	voice.LanguageIndonesian: {
+		voice.IntentUndo:      "Batalkan langkah terakhir?",
+		voice.IntentResign:    "Yakin mau menyerah?",
+		voice.IntentDrawOffer: "Tawarkan remis?",
+		promptNoMoves:         "Belum ada langkah yang dimainkan.",
+		promptNoGame:          "Langkah terakhir hanya bisa dibacakan untuk permainan yang tersimpan.",
+	},
+}
+
+const (
+	promptNoMoves = "no_moves"
+	promptNoGame  = "no_game"
+)
+
+// Prompt is the spoken reply to a voice intent that is not answered from
+// the position: undo, resign and draw offers are asked back for
+// confirmation.
+func Prompt(intent string, options models.NarrationOptions) string {
+	options = Options(options)
+	return prompts[options.Language][intent]
+}
+
+// NoMoves says that a game has no moves to read out.
+func NoMoves(options models.NarrationOptions) string {
+	return Prompt(promptNoMoves, options)
+}
+
+// NoGame says that the last move is unknown because no game was given.
+func NoGame(options models.NarrationOptions) string {
+	return Prompt(promptNoGame, options)
+}
+
+// Answer answers a single board query ("what is on d4?", "where are my
+// knights?") without describing the rest of the board.
+func Answer(position *chess.Position, query Query, options models.NarrationOptions) models.BoardQueryResult {
+	options = Options(options)
+	return answerQuery(position, query, vocabulary[options.Language], options.Language)
+}
+
+// Attackers says which pieces attack square: the opponent's pieces when a
+// piece stands there ("White queen on d1 is attacked by black bishop on
+// b3."), otherwise the pieces of the side not to move.
+func Attackers(position *chess.Position, square chess.Square, options models.NarrationOptions) string {
+	options = Options(options)
+	words := vocabulary[options.Language]
+	language := options.Language
+
+	board := position.Board()
+	piece := board.Piece(square)
+
+	subject := square.String()
+	attacker := position.Turn().Other()
+	if piece != chess.NoPiece {
+		subject = pieceWithColor(piece.Type(), piece.Color(), words, language) + " " + words.on + " " + square.String()
+		attacker = piece.Color().Other()
+	}
+
+	squares := tactics.Attackers(board, square, attacker)
+	if len(squares) == 0 {
+		return capitalize(subject) + " " + words.notAttacked + "."
+	}
+
+	phrases := make([]string, len(squares))
+	for i, from := range squares {
+		attacking := board.Piece(from)
+		phrases[i] = pieceWithColor(attacking.Type(), attacking.Color(), words, language) + " " + words.on + " " + from.String()
+	}
+	return capitalize(subject) + " " + words.attackedBy + " " + joinList(phrases, words) + "."
+}
+
+// PieceAttackers answers "what attacks my queen?" for every piece of that
+// kind and color on the board.
+func PieceAttackers(position *chess.Position, piece chess.Piece, options models.NarrationOptions) string {
+	options = Options(options)
+	words := vocabulary[options.Language]
+
+	squares := pieceSquares(position.Board(), piece, 0)
+	if len(squares) == 0 {
+		return capitalize(words.none) + " " + pieceGroupName(piece, 2, words, options.Language) + "."
+	}
+
+	answer := ""
+	for i, square := range squares {
+		if i > 0 {
+			answer += " "
+		}
+		answer += Attackers(position, square, options)
+	}
+	return answer
+}
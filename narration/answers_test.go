@@ -0,0 +1,76 @@
+package narration
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/voice"
+)
+
+func TestPrompt(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"undo", Prompt(voice.IntentUndo, english), "Take back your last move?"},
+		{"resign in indonesian", Prompt(voice.IntentResign, indonesian), "Yakin mau menyerah?"},
+		{"draw offer defaults to english", Prompt(voice.IntentDrawOffer, terse), "Offer a draw?"},
+		{"no moves", NoMoves(english), "No moves have been played yet."},
+		{"no game", NoGame(indonesian), "Langkah terakhir hanya bisa dibacakan untuk permainan yang tersimpan."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttackers(t *testing.T) {
+	// The black bishop on b3 attacks the white queen on d1 and the empty c2.
+	const fen = "4k3/8/8/8/8/1b6/8/3QK3 w - - 0 1"
+
+	tests := []struct {
+		name   string
+		square chess.Square
+		want   string
+	}{
+		{"attacked piece", chess.D1, "White queen on d1 is attacked by black bishop on b3."},
+		{"attacked empty square", chess.C2, "C2 is attacked by black bishop on b3."},
+		{"safe square", chess.E4, "E4 is not attacked."},
+		{"attacker attacked back", chess.B3, "Black bishop on b3 is attacked by white queen on d1."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Attackers(position(t, fen), tt.square, english); got != tt.want {
+				t.Errorf("Attackers(%s) = %q, want %q", tt.square, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPieceAttackers(t *testing.T) {
+	const fen = "4k3/8/8/8/8/1b6/8/3QK3 w - - 0 1"
+
+	tests := []struct {
+		name  string
+		piece chess.Piece
+		want  string
+	}{
+		{"attacked queen", chess.WhiteQueen, "White queen on d1 is attacked by black bishop on b3."},
+		{"missing piece", chess.BlackQueen, "There are no black queens."},
+		{"unattacked king", chess.BlackKing, "Black king on e8 is not attacked."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PieceAttackers(position(t, fen), tt.piece, english); got != tt.want {
+				t.Errorf("PieceAttackers(%s) = %q, want %q", tt.piece, got, tt.want)
+			}
+		})
+	}
+}
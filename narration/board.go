@@ -0,0 +1,327 @@
+package narration
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/models"
+	"samsungvoicebe/tactics"
+	"samsungvoicebe/voice"
+)
+
+// pieceOrder is the order pieces are listed in, most valuable first.
+var pieceOrder = []chess.PieceType{chess.King, chess.Queen, chess.Rook, chess.Bishop, chess.Knight, chess.Pawn}
+
+// pieceValues are the usual material points, counted in pawns.
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   1,
+	chess.Knight: 3,
+	chess.Bishop: 3,
+	chess.Rook:   5,
+	chess.Queen:  9,
+}
+
+// Query narrows a board description to a square, a rank or one type of
+// piece. Square is chess.NoSquare and the other fields are zero when unused;
+// Color defaults to the side to move for piece queries.
+type Query struct {
+	Square chess.Square
+	Rank   int
+	Piece  chess.PieceType
+	Color  chess.Color
+}
+
+// Empty reports whether the query asks for nothing in particular.
+func (q Query) Empty() bool {
+	return q.Square == chess.NoSquare && q.Rank == 0 && q.Piece == chess.NoPieceType && q.Color == chess.NoColor
+}
+
+// Board describes position for a player who cannot see it: the pieces of
+// each side grouped by type, material, whose turn it is, check and castling
+// rights. A non-empty query is answered in the description's Query field.
+//
+//	terse:       "White to move. White is up 2 points."
+//	normal:      adds every piece ("White: king on g1, rooks on a1 and f1, ...")
+//	             and castling rights
+//	descriptive: also mentions an en passant square
+func Board(position *chess.Position, query Query, options models.NarrationOptions) models.BoardDescription {
+	options = Options(options)
+	words := vocabulary[options.Language]
+	language := options.Language
+
+	board := position.Board()
+	turn := position.Turn()
+	castleRights := position.CastleRights()
+
+	description := models.BoardDescription{
+		Fen:     position.String(),
+		Turn:    colorKey(turn),
+		Status:  "ongoing",
+		InCheck: tactics.InCheck(position),
+	}
+	switch position.Status() {
+	case chess.Checkmate:
+		description.Status = "checkmate"
+	case chess.Stalemate:
+		description.Status = "stalemate"
+	}
+	if square := position.EnPassantSquare(); square != chess.NoSquare {
+		description.EnPassant = square.String()
+	}
+
+	sides := map[chess.Color]*models.SideDescription{
+		chess.White: &description.White,
+		chess.Black: &description.Black,
+	}
+	for color, side := range sides {
+		side.Pieces = []models.PieceGroup{}
+		for _, pieceType := range pieceOrder {
+			squares := pieceSquares(board, chess.NewPiece(pieceType, color), 0)
+			if len(squares) == 0 {
+				continue
+			}
+			side.Pieces = append(side.Pieces, models.PieceGroup{
+				Piece:   voice.PieceName(pieceType, voice.LanguageEnglish),
+				Squares: squareNames(squares),
+			})
+			side.Material += pieceValues[pieceType] * len(squares)
+		}
+		side.CanCastleKingside = castleRights.CanCastle(color, chess.KingSide)
+		side.CanCastleQueenside = castleRights.CanCastle(color, chess.QueenSide)
+	}
+	description.MaterialBalance = description.White.Material - description.Black.Material
+
+	var spoken []string
+	switch {
+	case description.Status == "checkmate":
+		spoken = append(spoken, capitalize(words.checkmate)+", "+colorName(turn.Other(), words)+" "+words.wins)
+	case description.Status == "stalemate":
+		spoken = append(spoken, words.stalemate)
+	case description.InCheck:
+		spoken = append(spoken, turnPhrase(turn, words), capitalize(colorName(turn, words))+" "+words.inCheck)
+	default:
+		spoken = append(spoken, turnPhrase(turn, words))
+	}
+
+	if options.Verbosity != VerbosityTerse {
+		for _, color := range []chess.Color{chess.White, chess.Black} {
+			spoken = append(spoken, capitalize(colorName(color, words))+": "+listPieces(board, color, words, language))
+		}
+	}
+
+	spoken = append(spoken, materialPhrase(description.MaterialBalance, words))
+
+	if options.Verbosity != VerbosityTerse {
+		for _, color := range []chess.Color{chess.White, chess.Black} {
+			spoken = append(spoken, castlingPhrase(*sides[color], color, words))
+		}
+	}
+	if options.Verbosity == VerbosityDescriptive && description.EnPassant != "" {
+		spoken = append(spoken, words.enPassantPossible+" "+description.EnPassant)
+	}
+
+	description.Spoken = strings.Join(spoken, ". ") + "."
+
+	if !query.Empty() {
+		result := answerQuery(position, query, words, language)
+		description.Query = &result
+	}
+
+	return description
+}
+
+// answerQuery answers "what is on d4?", "where are my knights?" and "what is
+// on the fourth rank?".
+func answerQuery(position *chess.Position, query Query, words phrases, language string) models.BoardQueryResult {
+	board := position.Board()
+
+	if query.Square != chess.NoSquare {
+		piece := board.Piece(query.Square)
+		if piece == chess.NoPiece {
+			return models.BoardQueryResult{
+				Squares: []models.OccupiedSquare{},
+				Spoken:  query.Square.String() + " " + words.empty + ".",
+			}
+		}
+		return models.BoardQueryResult{
+			Squares: []models.OccupiedSquare{occupiedSquare(query.Square, piece)},
+			Spoken:  capitalize(pieceWithColor(piece.Type(), piece.Color(), words, language)) + " " + words.on + " " + query.Square.String() + ".",
+		}
+	}
+
+	if query.Piece != chess.NoPieceType {
+		color := query.Color
+		if color == chess.NoColor {
+			color = position.Turn()
+		}
+		piece := chess.NewPiece(query.Piece, color)
+		squares := pieceSquares(board, piece, query.Rank)
+
+		result := models.BoardQueryResult{Squares: []models.OccupiedSquare{}}
+		for _, square := range squares {
+			result.Squares = append(result.Squares, occupiedSquare(square, piece))
+		}
+		if len(squares) == 0 {
+			result.Spoken = capitalize(words.none) + " " + pieceGroupName(piece, 2, words, language) + "."
+		} else {
+			result.Spoken = capitalize(pieceGroupName(piece, len(squares), words, language)) + " " + words.on + " " + joinList(squareNames(squares), words) + "."
+		}
+		return result
+	}
+
+	result := models.BoardQueryResult{Squares: []models.OccupiedSquare{}}
+	var phrases []string
+	for _, square := range sortedSquares(board) {
+		piece := board.Piece(square)
+		if query.Rank != 0 && int(square.Rank())+1 != query.Rank {
+			continue
+		}
+		if query.Color != chess.NoColor && piece.Color() != query.Color {
+			continue
+		}
+		result.Squares = append(result.Squares, occupiedSquare(square, piece))
+		phrases = append(phrases, pieceWithColor(piece.Type(), piece.Color(), words, language)+" "+words.on+" "+square.String())
+	}
+
+	var subject string
+	switch {
+	case query.Rank != 0:
+		subject = capitalize(words.rank) + " " + strconv.Itoa(query.Rank)
+	default:
+		subject = capitalize(colorName(query.Color, words))
+	}
+	if len(phrases) == 0 {
+		result.Spoken = subject + " " + words.empty + "."
+	} else {
+		result.Spoken = subject + ": " + joinList(phrases, words) + "."
+	}
+	return result
+}
+
+// listPieces is "king on g1, rooks on a1 and f1, pawns on f2, g2 and h2".
+func listPieces(board *chess.Board, color chess.Color, words phrases, language string) string {
+	var groups []string
+	for _, pieceType := range pieceOrder {
+		piece := chess.NewPiece(pieceType, color)
+		squares := pieceSquares(board, piece, 0)
+		if len(squares) == 0 {
+			continue
+		}
+		name := voice.PieceName(pieceType, language)
+		if len(squares) > 1 && words.pluralPieces {
+			name += "s"
+		}
+		groups = append(groups, name+" "+words.on+" "+joinList(squareNames(squares), words))
+	}
+	return strings.Join(groups, ", ")
+}
+
+// pieceGroupName is "white knights" or "kuda putih"; Indonesian nouns do not
+// change in the plural.
+func pieceGroupName(piece chess.Piece, count int, words phrases, language string) string {
+	name := voice.PieceName(piece.Type(), language)
+	if count > 1 && words.pluralPieces {
+		name += "s"
+	}
+	if words.colorAfterNoun {
+		return name + " " + colorName(piece.Color(), words)
+	}
+	return colorName(piece.Color(), words) + " " + name
+}
+
+func materialPhrase(balance int, words phrases) string {
+	if balance == 0 {
+		return words.materialEven
+	}
+
+	leader := chess.White
+	if balance < 0 {
+		leader, balance = chess.Black, -balance
+	}
+	unit := words.points
+	if balance == 1 {
+		unit = words.point
+	}
+	return capitalize(colorName(leader, words)) + " " + words.materialAhead + " " + strconv.Itoa(balance) + " " + unit
+}
+
+func castlingPhrase(side models.SideDescription, color chess.Color, words phrases) string {
+	name := capitalize(colorName(color, words))
+	switch {
+	case side.CanCastleKingside && side.CanCastleQueenside:
+		return name + " " + words.canCastle + " " + words.bothWays
+	case side.CanCastleKingside:
+		return name + " " + words.canCastle + " " + words.kingside
+	case side.CanCastleQueenside:
+		return name + " " + words.canCastle + " " + words.queenside
+	default:
+		return name + " " + words.cannotCastle
+	}
+}
+
+// pieceSquares returns where piece stands, limited to one rank when rank is
+// not zero.
+func pieceSquares(board *chess.Board, piece chess.Piece, rank int) []chess.Square {
+	var squares []chess.Square
+	for _, square := range sortedSquares(board) {
+		if board.Piece(square) != piece {
+			continue
+		}
+		if rank != 0 && int(square.Rank())+1 != rank {
+			continue
+		}
+		squares = append(squares, square)
+	}
+	return squares
+}
+
+// sortedSquares lists the occupied squares file by file, a1 to h8, so pieces
+// are read out in a stable order.
+func sortedSquares(board *chess.Board) []chess.Square {
+	var squares []chess.Square
+	for square := range board.SquareMap() {
+		squares = append(squares, square)
+	}
+	sort.Slice(squares, func(i, j int) bool {
+		if squares[i].File() != squares[j].File() {
+			return squares[i].File() < squares[j].File()
+		}
+		return squares[i].Rank() < squares[j].Rank()
+	})
+	return squares
+}
+
+func occupiedSquare(square chess.Square, piece chess.Piece) models.OccupiedSquare {
+	return models.OccupiedSquare{
+		Square: square.String(),
+		Piece:  voice.PieceName(piece.Type(), voice.LanguageEnglish),
+		Color:  colorKey(piece.Color()),
+	}
+}
+
+// colorKey is the color as it appears in JSON: "white" or "black".
+func colorKey(color chess.Color) string {
+	return strings.ToLower(color.Name())
+}
+
+func squareNames(squares []chess.Square) []string {
+	names := make([]string, len(squares))
+	for i, square := range squares {
+		names[i] = square.String()
+	}
+	return names
+}
+
+// joinList is "a1, b1 and c1".
+func joinList(items []string, words phrases) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	default:
+		return strings.Join(items[:len(items)-1], ", ") + " " + words.and + " " + items[len(items)-1]
+	}
+}
@@ -0,0 +1,138 @@
+package narration
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/models"
+	"samsungvoicebe/voice"
+)
+
+func TestBoard(t *testing.T) {
+	tests := []struct {
+		name        string
+		fen         string
+		options     models.NarrationOptions
+		wantSpoken  string
+		wantStatus  string
+		wantBalance int
+	}{
+		{
+			name:        "normal",
+			fen:         "4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1",
+			options:     english,
+			wantSpoken:  "White to move. White: king on e1, rooks on a1 and h1. Black: king on e8. White is up 10 points. White can castle both ways. Black cannot castle.",
+			wantStatus:  "ongoing",
+			wantBalance: 10,
+		},
+		{
+			name:        "terse",
+			fen:         "4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1",
+			options:     terse,
+			wantSpoken:  "White to move. White is up 10 points.",
+			wantStatus:  "ongoing",
+			wantBalance: 10,
+		},
+		{
+			name:        "indonesian",
+			fen:         "4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1",
+			options:     models.NarrationOptions{Language: voice.LanguageIndonesian, Verbosity: VerbosityTerse},
+			wantSpoken:  "Giliran jalan putih. Putih unggul 10 poin.",
+			wantStatus:  "ongoing",
+			wantBalance: 10,
+		},
+		{
+			name:        "in check",
+			fen:         "R3k3/8/8/8/8/8/8/4K3 b - - 0 1",
+			options:     terse,
+			wantSpoken:  "Black to move. Black is in check. White is up 5 points.",
+			wantStatus:  "ongoing",
+			wantBalance: 5,
+		},
+		{
+			name:        "black ahead by a point",
+			fen:         "4k3/4p3/8/8/8/8/8/4K3 w - - 0 1",
+			options:     terse,
+			wantSpoken:  "White to move. Black is up 1 point.",
+			wantStatus:  "ongoing",
+			wantBalance: -1,
+		},
+		{
+			name:        "checkmate",
+			fen:         "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3",
+			options:     terse,
+			wantSpoken:  "Checkmate, black wins. Material is even.",
+			wantStatus:  "checkmate",
+			wantBalance: 0,
+		},
+		{
+			name:        "stalemate",
+			fen:         "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1",
+			options:     terse,
+			wantSpoken:  "Stalemate, the game is a draw. White is up 9 points.",
+			wantStatus:  "stalemate",
+			wantBalance: 9,
+		},
+		{
+			name:        "descriptive en passant",
+			fen:         "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1",
+			options:     descriptive,
+			wantSpoken:  "White to move. White: king on e1, pawn on e5. Black: king on e8, pawn on d5. Material is even. White cannot castle. Black cannot castle. En passant is possible on d6.",
+			wantStatus:  "ongoing",
+			wantBalance: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Board(position(t, tt.fen), Query{Square: chess.NoSquare}, tt.options)
+
+			if got.Spoken != tt.wantSpoken {
+				t.Errorf("Board().Spoken = %q, want %q", got.Spoken, tt.wantSpoken)
+			}
+			if got.Status != tt.wantStatus || got.MaterialBalance != tt.wantBalance {
+				t.Errorf("Board() status %q balance %d, want %q %d", got.Status, got.MaterialBalance, tt.wantStatus, tt.wantBalance)
+			}
+			if got.Query != nil {
+				t.Errorf("Board() answered an empty query: %+v", got.Query)
+			}
+		})
+	}
+}
+
+func TestBoardQuery(t *testing.T) {
+	const fen = "4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1"
+
+	tests := []struct {
+		name        string
+		query       Query
+		options     models.NarrationOptions
+		wantSpoken  string
+		wantSquares int
+	}{
+		{"empty square", Query{Square: chess.E4}, english, "e4 is empty.", 0},
+		{"occupied square", Query{Square: chess.A1}, english, "White rook on a1.", 1},
+		{"indonesian square", Query{Square: chess.A1}, indonesian, "Benteng putih di a1.", 1},
+		{"pieces of the side to move", Query{Square: chess.NoSquare, Piece: chess.Rook}, english, "White rooks on a1 and h1.", 2},
+		{"indonesian pieces", Query{Square: chess.NoSquare, Piece: chess.Rook}, indonesian, "Benteng putih di a1 dan h1.", 2},
+		{"missing pieces", Query{Square: chess.NoSquare, Piece: chess.Queen, Color: chess.Black}, english, "There are no black queens.", 0},
+		{"rank", Query{Square: chess.NoSquare, Rank: 1}, english, "Rank 1: white rook on a1, white king on e1 and white rook on h1.", 3},
+		{"empty rank", Query{Square: chess.NoSquare, Rank: 4}, english, "Rank 4 is empty.", 0},
+		{"one side", Query{Square: chess.NoSquare, Color: chess.Black}, english, "Black: black king on e8.", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Board(position(t, fen), tt.query, tt.options).Query
+			if got == nil {
+				t.Fatal("Board() did not answer the query")
+			}
+			if got.Spoken != tt.wantSpoken {
+				t.Errorf("query spoken = %q, want %q", got.Spoken, tt.wantSpoken)
+			}
+			if len(got.Squares) != tt.wantSquares {
+				t.Errorf("query squares = %v, want %d", got.Squares, tt.wantSquares)
+			}
+		})
+	}
+}
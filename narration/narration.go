@@ -0,0 +1,251 @@
+// Package narration turns moves into sentences a screen reader or TTS engine
+// can speak, in English or Indonesian.
+package narration
+
+import (
+	"strings"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/models"
+	"samsungvoicebe/voice"
+)
+
+const (
+	VerbosityTerse       = "terse"
+	VerbosityNormal      = "normal"
+	VerbosityDescriptive = "descriptive"
+)
+
+type phrases struct {
+	white, black     string
+	to, takes, on    string
+	from             string
+	enPassant        string
+	promotes         string
+	castlesKingside  string
+	castlesQueenside string
+	check, checkmate string
+	wins             string
+	stalemate        string
+	toMove           string
+	inCheck          string
+	colorAfterNoun   bool
+
+	and, empty, rank  string
+	none              string
+	materialEven      string
+	materialAhead     string
+	point, points     string
+	canCastle         string
+	cannotCastle      string
+	kingside          string
+	queenside         string
+	bothWays          string
+	enPassantPossible string
+	pluralPieces      bool
+
+	attackedBy  string
+	notAttacked string
+}
+
+var vocabulary = map[string]phrases{
+	voice.LanguageEnglish: {
+		white: "white", black: "black",
+		to: "to", takes: "takes", on: "on", from: "from",
+		enPassant:        "en passant",
+		promotes:         "promotes to",
+		castlesKingside:  "castles kingside",
+		castlesQueenside: "castles queenside",
+		check:            "check",
+		checkmate:        "checkmate",
+		wins:             "wins",
+		stalemate:        "Stalemate, the game is a draw",
+		toMove:           "to move",
+		inCheck:          "is in check",
+
+		and: "and", empty: "is empty", rank: "rank",
+		none:              "there are no",
+		materialEven:      "Material is even",
+		materialAhead:     "is up",
+		point:             "point",
+		points:            "points",
+		canCastle:         "can castle",
+		cannotCastle:      "cannot castle",
+		kingside:          "kingside",
+		queenside:         "queenside",
+		bothWays:          "both ways",
+		enPassantPossible: "En passant is possible on",
+		pluralPieces:      true,
+
+		attackedBy:  "is attacked by",
+		notAttacked: "is not attacked",
+	},
+	voice.LanguageIndonesian: {
+		white: "putih", black: "hitam",
+		to: "ke", takes: "memakan", on: "di", from: "dari",
+		enPassant:        "en passant",
+		promotes:         "promosi menjadi",
+		castlesKingside:  "rokade pendek",
+		castlesQueenside: "rokade panjang",
+		check:            "skak",
+		checkmate:        "skakmat",
+		wins:             "menang",
+		stalemate:        "Pat, permainan remis",
+		toMove:           "giliran jalan",
+		inCheck:          "sedang diskak",
+		colorAfterNoun:   true,
+
+		and: "dan", empty: "kosong", rank: "baris",
+		none:              "tidak ada",
+		materialEven:      "Material seimbang",
+		materialAhead:     "unggul",
+		point:             "poin",
+		points:            "poin",
+		canCastle:         "bisa rokade",
+		cannotCastle:      "tidak bisa rokade",
+		kingside:          "pendek",
+		queenside:         "panjang",
+		bothWays:          "pendek dan panjang",
+		enPassantPossible: "En passant bisa di",
+
+		attackedBy:  "diserang oleh",
+		notAttacked: "tidak diserang",
+	},
+}
+
+// Options picks the language and verbosity of a narration. Empty fields fall
+// back to English and normal verbosity.
+func Options(options models.NarrationOptions) models.NarrationOptions {
+	if _, ok := vocabulary[options.Language]; !ok {
+		options.Language = voice.LanguageEnglish
+	}
+	switch options.Verbosity {
+	case VerbosityTerse, VerbosityNormal, VerbosityDescriptive:
+	default:
+		options.Verbosity = VerbosityNormal
+	}
+	return options
+}
+
+// Move narrates move played from position, e.g. "Black knight takes pawn on
+// e5, check." or "Kuda hitam memakan pion di e5, skak.".
+//
+//	terse:       "Knight takes e5, check."
+//	normal:      "Black knight takes pawn on e5, check."
+//	descriptive: "Black knight from f6 takes white pawn on e5. Check! White to move."
+func Move(position *chess.Position, move *chess.Move, options models.NarrationOptions) string {
+	options = Options(options)
+	words := vocabulary[options.Language]
+	language := options.Language
+
+	board := position.Board()
+	mover := board.Piece(move.S1())
+	after := position.Update(move)
+	status := after.Status()
+
+	var sentence []string
+	switch {
+	case move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle):
+		castles := words.castlesKingside
+		if move.HasTag(chess.QueenSideCastle) {
+			castles = words.castlesQueenside
+		}
+		if options.Verbosity == VerbosityTerse {
+			sentence = append(sentence, castles)
+		} else {
+			sentence = append(sentence, colorName(mover.Color(), words), castles)
+		}
+
+	default:
+		if options.Verbosity == VerbosityTerse {
+			sentence = append(sentence, voice.PieceName(mover.Type(), language))
+		} else {
+			sentence = append(sentence, pieceWithColor(mover.Type(), mover.Color(), words, language))
+		}
+		if options.Verbosity == VerbosityDescriptive {
+			sentence = append(sentence, words.from, move.S1().String())
+		}
+
+		captured := capturedPiece(board, move)
+		switch {
+		case captured == chess.NoPiece:
+			sentence = append(sentence, words.to, move.S2().String())
+		case options.Verbosity == VerbosityTerse:
+			sentence = append(sentence, words.takes, move.S2().String())
+		case options.Verbosity == VerbosityDescriptive:
+			sentence = append(sentence, words.takes, pieceWithColor(captured.Type(), captured.Color(), words, language), words.on, move.S2().String())
+		default:
+			sentence = append(sentence, words.takes, voice.PieceName(captured.Type(), language), words.on, move.S2().String())
+		}
+		if move.HasTag(chess.EnPassant) && options.Verbosity != VerbosityTerse {
+			sentence = append(sentence, words.enPassant)
+		}
+
+		if move.Promo() != chess.NoPieceType {
+			sentence[len(sentence)-1] += ","
+			sentence = append(sentence, words.promotes, voice.PieceName(move.Promo(), language))
+		}
+	}
+
+	text := strings.Join(sentence, " ")
+
+	switch {
+	case status == chess.Checkmate && options.Verbosity == VerbosityDescriptive:
+		text += ". " + capitalize(words.checkmate) + "! " + capitalize(colorName(mover.Color(), words)) + " " + words.wins + "."
+	case status == chess.Checkmate:
+		text += ", " + words.checkmate + "."
+	case status == chess.Stalemate:
+		text += ". " + words.stalemate + "."
+	case move.HasTag(chess.Check) && options.Verbosity == VerbosityDescriptive:
+		text += ". " + capitalize(words.check) + "! " + capitalize(colorName(after.Turn(), words)) + " " + words.inCheck + "."
+	case move.HasTag(chess.Check):
+		text += ", " + words.check + "."
+	case options.Verbosity == VerbosityDescriptive:
+		text += ". " + turnPhrase(after.Turn(), words) + "."
+	default:
+		text += "."
+	}
+
+	return capitalize(text)
+}
+
+// pieceWithColor is "black knight" in English and "kuda hitam" in
+// Indonesian, where the adjective follows the noun.
+func pieceWithColor(pieceType chess.PieceType, color chess.Color, words phrases, language string) string {
+	piece := voice.PieceName(pieceType, language)
+	if words.colorAfterNoun {
+		return piece + " " + colorName(color, words)
+	}
+	return colorName(color, words) + " " + piece
+}
+
+func turnPhrase(turn chess.Color, words phrases) string {
+	if words.colorAfterNoun {
+		return capitalize(words.toMove) + " " + colorName(turn, words)
+	}
+	return capitalize(colorName(turn, words)) + " " + words.toMove
+}
+
+func colorName(color chess.Color, words phrases) string {
+	if color == chess.Black {
+		return words.black
+	}
+	return words.white
+}
+
+func capturedPiece(board *chess.Board, move *chess.Move) chess.Piece {
+	if move.HasTag(chess.EnPassant) {
+		if board.Piece(move.S1()).Color() == chess.White {
+			return chess.BlackPawn
+		}
+		return chess.WhitePawn
+	}
+	return board.Piece(move.S2())
+}
+
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
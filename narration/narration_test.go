@@ -0,0 +1,87 @@
+package narration
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/helper"
+	"samsungvoicebe/models"
+	"samsungvoicebe/voice"
+)
+
+const startFen = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+var (
+	english     = models.NarrationOptions{Language: voice.LanguageEnglish}
+	indonesian  = models.NarrationOptions{Language: voice.LanguageIndonesian}
+	terse       = models.NarrationOptions{Verbosity: VerbosityTerse}
+	descriptive = models.NarrationOptions{Verbosity: VerbosityDescriptive}
+)
+
+func position(t *testing.T, fen string) *chess.Position {
+	t.Helper()
+	fenOption, err := chess.FEN(fen)
+	if err != nil {
+		t.Fatalf("chess.FEN(%q): %v", fen, err)
+	}
+	return chess.NewGame(fenOption).Position()
+}
+
+func TestMove(t *testing.T) {
+	const (
+		capture   = "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1"
+		enPassant = "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1"
+		castling  = "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+		promotion = "4k3/P7/8/8/8/8/8/4K3 w - - 0 1"
+		check     = "4k3/8/8/8/8/8/8/R3K3 w - - 0 1"
+		mate      = "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"
+		stalemate = "7k/8/5Q2/6K1/8/8/8/8 w - - 0 1"
+	)
+
+	tests := []struct {
+		name    string
+		fen     string
+		move    string
+		options models.NarrationOptions
+		want    string
+	}{
+		{"quiet move", startFen, "e2e4", english, "White pawn to e4."},
+		{"terse", startFen, "g1f3", terse, "Knight to f3."},
+		{"descriptive", startFen, "e2e4", descriptive, "White pawn from e2 to e4. Black to move."},
+		{"indonesian", startFen, "g1f3", indonesian, "Kuda putih ke f3."},
+		{"indonesian descriptive", startFen, "g1f3", models.NarrationOptions{Language: voice.LanguageIndonesian, Verbosity: VerbosityDescriptive}, "Kuda putih dari g1 ke f3. Giliran jalan hitam."},
+		{"unknown options fall back", startFen, "e2e4", models.NarrationOptions{Language: "fr", Verbosity: "loud"}, "White pawn to e4."},
+
+		{"capture", capture, "e4d5", english, "White pawn takes pawn on d5."},
+		{"terse capture", capture, "e4d5", terse, "Pawn takes d5."},
+		{"descriptive capture", capture, "e4d5", descriptive, "White pawn from e4 takes black pawn on d5. Black to move."},
+		{"indonesian capture", capture, "e4d5", indonesian, "Pion putih memakan pion di d5."},
+		{"en passant", enPassant, "e5d6", english, "White pawn takes pawn on d6 en passant."},
+
+		{"castles kingside", castling, "e1g1", english, "White castles kingside."},
+		{"terse castles queenside", castling, "e1c1", terse, "Castles queenside."},
+		{"indonesian castles", castling, "e1g1", indonesian, "Putih rokade pendek."},
+
+		{"under-promotion", promotion, "a7a8n", english, "White pawn to a8, promotes to knight."},
+		{"check", check, "a1a8", english, "White rook to a8, check."},
+		{"descriptive check", check, "a1a8", descriptive, "White rook from a1 to a8. Check! Black is in check."},
+		{"indonesian check", check, "a1a8", indonesian, "Benteng putih ke a8, skak."},
+		{"checkmate", mate, "a1a8", english, "White rook to a8, checkmate."},
+		{"descriptive checkmate", mate, "a1a8", descriptive, "White rook from a1 to a8. Checkmate! White wins."},
+		{"stalemate", stalemate, "f6f7", english, "White queen to f7. Stalemate, the game is a draw."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := position(t, tt.fen)
+			move, err := helper.DecodeMove(pos, tt.move)
+			if err != nil {
+				t.Fatalf("decoding %s: %v", tt.move, err)
+			}
+
+			if got := Move(pos, move, tt.options); got != tt.want {
+				t.Errorf("Move(%s) = %q, want %q", tt.move, got, tt.want)
+			}
+		})
+	}
+}
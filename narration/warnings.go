@@ -0,0 +1,68 @@
+package narration
+
+import (
+	"fmt"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/models"
+	"samsungvoicebe/tactics"
+	"samsungvoicebe/voice"
+)
+
+// warningTemplates are filled with the piece, its square and the attackers
+// (or, for a mate threat, the mating move).
+var warningTemplates = map[string]map[tactics.ThreatKind]string{
+	voice.LanguageEnglish: {
+		tactics.ThreatCheck:         "You are in check from %[3]s.",
+		tactics.ThreatHanging:       "Your %[1]s on %[2]s is attacked by %[3]s, and is not defended.",
+		tactics.ThreatUnderdefended: "Your %[1]s on %[2]s is attacked by %[3]s, and is not defended enough.",
+		tactics.ThreatPinned:        "Your %[1]s on %[2]s is pinned to your king by %[3]s.",
+		tactics.ThreatMate:          "Your opponent threatens checkmate with %[3]s.",
+	},
+	voice.LanguageIndonesian: {
+		tactics.ThreatCheck:         "Kamu sedang diskak oleh %[3]s.",
+		tactics.ThreatHanging:       "%[1]s kamu di %[2]s diserang oleh %[3]s, dan tidak dilindungi.",
+		tactics.ThreatUnderdefended: "%[1]s kamu di %[2]s diserang oleh %[3]s, dan kurang dilindungi.",
+		tactics.ThreatPinned:        "%[1]s kamu di %[2]s terkunci ke raja oleh %[3]s.",
+		tactics.ThreatMate:          "Lawan mengancam skakmat dengan %[3]s.",
+	},
+}
+
+// Warnings lists the threats facing the side to move, each with a sentence
+// to read out, e.g. "Your queen on d1 is attacked by knight on e3, and is
+// not defended." or "Lawan mengancam skakmat dengan ratu ke f7.".
+func Warnings(position *chess.Position, options models.NarrationOptions) []models.Warning {
+	options = Options(options)
+	words := vocabulary[options.Language]
+	language := options.Language
+	board := position.Board()
+
+	warnings := []models.Warning{}
+	for _, threat := range tactics.Threats(position) {
+		warning := models.Warning{
+			Type:      string(threat.Kind),
+			Square:    threat.Square.String(),
+			Piece:     voice.PieceName(threat.Piece.Type(), voice.LanguageEnglish),
+			Attackers: squareNames(threat.Attackers),
+			Defenders: squareNames(threat.Defenders),
+		}
+
+		var culprits string
+		if threat.Move != nil {
+			warning.Move = threat.Move.String()
+			mover := board.Piece(threat.Move.S1())
+			culprits = voice.PieceName(mover.Type(), language) + " " + words.to + " " + threat.Move.S2().String()
+		} else {
+			phrases := make([]string, len(threat.Attackers))
+			for i, square := range threat.Attackers {
+				phrases[i] = voice.PieceName(board.Piece(square).Type(), language) + " " + words.on + " " + square.String()
+			}
+			culprits = joinList(phrases, words)
+		}
+
+		spoken := fmt.Sprintf(warningTemplates[language][threat.Kind], voice.PieceName(threat.Piece.Type(), language), threat.Square.String(), culprits)
+		warning.Spoken = capitalize(spoken)
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
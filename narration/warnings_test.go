@@ -0,0 +1,59 @@
+package narration
+
+import (
+	"reflect"
+	"testing"
+
+	"samsungvoicebe/models"
+)
+
+func TestWarnings(t *testing.T) {
+	// After 1. e4 e5 2. Bc4 Nc6 3. Qh5 black has to cover f7.
+	const scholarsMate = "r1bqkbnr/pppp1ppp/2n5/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 3 3"
+
+	tests := []struct {
+		name    string
+		fen     string
+		options models.NarrationOptions
+		want    []string
+	}{
+		{"quiet position", startFen, english, []string{}},
+		{"check", "R3k3/8/8/8/8/8/8/4K3 b - - 0 1", english, []string{"You are in check from rook on a8."}},
+		{"hanging piece", "4k3/8/8/8/8/1b6/8/3N3K w - - 0 1", english, []string{"Your knight on d1 is attacked by bishop on b3, and is not defended."}},
+		{"underdefended piece", "4k3/8/8/8/8/1b6/8/3QK3 w - - 0 1", english, []string{"Your queen on d1 is attacked by bishop on b3, and is not defended enough."}},
+		{"pin", "4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1", english, []string{"Your knight on e2 is pinned to your king by rook on e7."}},
+		{"indonesian pin", "4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1", indonesian, []string{"Kuda kamu di e2 terkunci ke raja oleh benteng di e7."}},
+		{
+			name:    "mate threat",
+			fen:     scholarsMate,
+			options: english,
+			want: []string{
+				"Your pawn on f7 is attacked by queen on h5 and bishop on c4, and is not defended enough.",
+				"Your pawn on f7 is pinned to your king by queen on h5.",
+				"Your opponent threatens checkmate with queen to f7.",
+			},
+		},
+		{
+			name:    "indonesian mate threat",
+			fen:     scholarsMate,
+			options: indonesian,
+			want: []string{
+				"Pion kamu di f7 diserang oleh ratu di h5 dan gajah di c4, dan kurang dilindungi.",
+				"Pion kamu di f7 terkunci ke raja oleh ratu di h5.",
+				"Lawan mengancam skakmat dengan ratu ke f7.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, warning := range Warnings(position(t, tt.fen), tt.options) {
+				got = append(got, warning.Spoken)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Warnings() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package pending keeps short-lived server-side state (voice disambiguation
+// questions, moves awaiting confirmation) behind random tokens.
+package pending
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type entry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// Store holds values for ttl after they are put. Expired values are dropped
+// lazily; a Store is safe for concurrent use.
+type Store[T any] struct {
+	ttl   time.Duration
+	mu    sync.Mutex
+	items map[string]entry[T]
+}
+
+func NewStore[T any](ttl time.Duration) *Store[T] {
+	if ttl <= 0 {
+		ttl = 2 * time.Minute
+	}
+	return &Store[T]{
+		ttl:   ttl,
+		items: map[string]entry[T]{},
+	}
+}
+
+// Put stores value under a new token and returns the token and its expiry.
+func (s *Store[T]) Put(value T) (string, time.Time) {
+	token := newToken()
+	expiresAt := time.Now().Add(s.ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	s.items[token] = entry[T]{value: value, expiresAt: expiresAt}
+	return token, expiresAt
+}
+
+// Take returns the value for token and removes it, so a token can only be
+// used once.
+func (s *Store[T]) Take(token string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[token]
+	delete(s.items, token)
+	if !ok || time.Now().After(item.expiresAt) {
+		var zero T
+		return zero, false
+	}
+	return item.value, true
+}
+
+func (s *Store[T]) sweep() {
+	now := time.Now()
+	for token, item := range s.items {
+		if now.After(item.expiresAt) {
+			delete(s.items, token)
+		}
+	}
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
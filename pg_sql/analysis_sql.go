@@ -5,11 +5,13 @@ var (
 	SELECT
 		games.id as id,
 		games.created_at AS date,
-		COUNT(moves.id) AS move_amount
+		COUNT(moves.id) AS move_amount,
+		games.result AS result,
+		games.termination AS termination
 	FROM public.games
 		INNER JOIN public.moves ON moves.game_id = games.id
 	WHERE user_id = $1
-	GROUP BY games.id, games.created_at
+	GROUP BY games.id, games.created_at, games.result, games.termination
 	ORDER BY games.created_at DESC
 	`
 
@@ -17,4 +19,28 @@ var (
 	SELECT move, fen FROM public.moves
 		WHERE game_id = $1 AND move_order = $2;
 	`
+
+	CountTakebacks = `
+	SELECT COUNT(*) FROM public.takebacks
+		WHERE game_id = $1;
+	`
+
+	GetCachedAnalysis = `
+	SELECT score_cp, score_mate, best_move, pv FROM public.analyses
+		WHERE fen = $1 AND engine = $2 AND engine_version = $3 AND depth >= $4
+		ORDER BY depth DESC
+		LIMIT 1;
+	`
+
+	SaveAnalysis = `
+	INSERT INTO public.analyses (fen, engine, engine_version, depth, score_cp, score_mate, best_move, pv)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (fen, engine, depth) DO UPDATE SET
+		engine_version = EXCLUDED.engine_version,
+		score_cp = EXCLUDED.score_cp,
+		score_mate = EXCLUDED.score_mate,
+		best_move = EXCLUDED.best_move,
+		pv = EXCLUDED.pv,
+		updated_at = CURRENT_TIMESTAMP;
+	`
 )
@@ -2,11 +2,111 @@ package pg_sql
 
 var (
 	Move = `
-	INSERT INTO public.moves (game_id, fen, move)
-		VALUES ($1, $2, $3);
+	INSERT INTO public.moves (game_id, fen, move, move_order, created_at)
+		VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, '')::TIMESTAMP WITH TIME ZONE, CURRENT_TIMESTAMP));
+	`
+
+	LockActiveGame = `
+	SELECT id FROM public.games
+		WHERE id = $1 AND result = '*'
+		FOR UPDATE;
+	`
+
+	GetPlyCount = `
+	SELECT COALESCE(MAX(move_order), 0) FROM public.moves
+		WHERE game_id = $1;
 	`
 
 	CreateGame = `
-	INSERT INTO public.games (user_id) VALUES ($1) RETURNING id;
+	INSERT INTO public.games (user_id, player_color, bot_level, bot_elo, bot_personality, starting_fen, time_control)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;
+	`
+
+	GetGame = `
+	SELECT id, user_id, created_at, player_color, bot_level, bot_elo, bot_personality, starting_fen, time_control,
+		result, termination, draw_offered_by
+		FROM public.games
+		WHERE id = $1;
+	`
+
+	GetLastMove = `
+	SELECT move, fen, move_order FROM public.moves
+		WHERE game_id = $1
+		ORDER BY move_order DESC
+		LIMIT 1;
+	`
+
+	GetRecentMoves = `
+	SELECT move, fen, move_order FROM public.moves
+		WHERE game_id = $1
+		ORDER BY move_order DESC
+		LIMIT $2;
+	`
+
+	GetHintLevel = `
+	SELECT COALESCE(MAX(level), 0) FROM public.hints
+		WHERE game_id = $1 AND fen = $2;
+	`
+
+	SaveHint = `
+	INSERT INTO public.hints (game_id, fen, level, best_move)
+		VALUES ($1, $2, $3, $4);
+	`
+
+	FinishGame = `
+	UPDATE public.games
+		SET result = $2, termination = $3, draw_offered_by = '', ended_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND result = '*';
+	`
+
+	SetDrawOffer = `
+	UPDATE public.games
+		SET draw_offered_by = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND result = '*';
+	`
+
+	GetGameMoves = `
+	SELECT move, fen, move_order, created_at FROM public.moves
+		WHERE game_id = $1
+		ORDER BY move_order ASC;
+	`
+
+	GetUnfinishedGames = `
+	SELECT
+		games.id,
+		games.created_at,
+		games.player_color,
+		games.bot_level,
+		games.bot_elo,
+		games.bot_personality,
+		games.time_control,
+		COUNT(moves.id) AS move_count,
+		COALESCE(
+			(SELECT last_move.fen FROM public.moves AS last_move
+				WHERE last_move.game_id = games.id
+				ORDER BY last_move.move_order DESC
+				LIMIT 1),
+			games.starting_fen
+		) AS fen,
+		COALESCE(MAX(moves.created_at), games.created_at) AS last_played_at
+	FROM public.games
+		LEFT JOIN public.moves ON moves.game_id = games.id
+	WHERE games.user_id = $1 AND games.result = '*'
+	GROUP BY games.id
+	ORDER BY last_played_at DESC;
+	`
+
+	DeleteMovesAfter = `
+	DELETE FROM public.moves
+		WHERE game_id = $1 AND move_order > $2;
+	`
+
+	SaveTakeback = `
+	INSERT INTO public.takebacks (game_id, plies, from_move_order, moves, fen_before, fen_after)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+
+	DeleteGame = `
+	DELETE FROM public.games WHERE id = $1;
 	`
 )
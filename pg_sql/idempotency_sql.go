@@ -0,0 +1,35 @@
+package pg_sql
+
+var (
+	DeleteExpiredIdempotencyKeys = `
+	DELETE FROM public.idempotency_keys
+		WHERE expires_at < CURRENT_TIMESTAMP;
+	`
+
+	ReserveIdempotencyKey = `
+	INSERT INTO public.idempotency_keys (idempotency_key, scope, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (idempotency_key, scope) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash, status_code = NULL, response = NULL,
+				created_at = CURRENT_TIMESTAMP, expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < $5
+		RETURNING id;
+	`
+
+	GetIdempotencyKey = `
+	SELECT idempotency_key, scope, request_hash, COALESCE(status_code, 0), COALESCE(response, ''), expires_at
+		FROM public.idempotency_keys
+		WHERE idempotency_key = $1 AND scope = $2;
+	`
+
+	CompleteIdempotencyKey = `
+	UPDATE public.idempotency_keys
+		SET status_code = $3, response = $4
+		WHERE idempotency_key = $1 AND scope = $2;
+	`
+
+	ReleaseIdempotencyKey = `
+	DELETE FROM public.idempotency_keys
+		WHERE idempotency_key = $1 AND scope = $2 AND status_code IS NULL;
+	`
+)
@@ -0,0 +1,33 @@
+package pg_sql
+
+var (
+	CreateSchemaMigrations = `
+	CREATE TABLE IF NOT EXISTS public.schema_migrations (
+		version BIGINT PRIMARY KEY NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	LockMigrations = `
+	SELECT pg_advisory_lock($1);
+	`
+
+	UnlockMigrations = `
+	SELECT pg_advisory_unlock($1);
+	`
+
+	GetAppliedMigrations = `
+	SELECT version, applied_at FROM public.schema_migrations;
+	`
+
+	RecordMigration = `
+	INSERT INTO public.schema_migrations (version, name)
+		VALUES ($1, $2);
+	`
+
+	ForgetMigration = `
+	DELETE FROM public.schema_migrations
+		WHERE version = $1;
+	`
+)
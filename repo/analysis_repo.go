@@ -2,6 +2,7 @@ package repo
 
 import (
 	"database/sql"
+	"strings"
 
 	"samsungvoicebe/models"
 	"samsungvoicebe/pg_sql"
@@ -34,7 +35,7 @@ func (r *AnalysisRepo) GetGameHistoryList(userID string) ([]models.Game, error)
 
 	for rows.Next() {
 		var game models.Game
-		if err := rows.Scan(&game.GameID, &game.Date, &game.MoveAmount); err != nil {
+		if err := rows.Scan(&game.GameID, &game.Date, &game.MoveAmount, &game.Result, &game.Termination); err != nil {
 			return []models.Game{}, err
 		}
 		games = append(games, game)
@@ -46,3 +47,83 @@ func (r *AnalysisRepo) GetGameHistoryList(userID string) ([]models.Game, error)
 
 	return games, nil
 }
+
+func (r *AnalysisRepo) GetGame(gameID string) (models.GameRecord, error) {
+	var game models.GameRecord
+	err := r.db.QueryRow(pg_sql.GetGame, gameID).Scan(
+		&game.ID, &game.UserID, &game.CreatedAt, &game.PlayerColor, &game.BotLevel,
+		&game.BotElo, &game.BotPersonality, &game.StartingFen, &game.TimeControl,
+		&game.Result, &game.Termination, &game.DrawOfferedBy,
+	)
+	if err != nil {
+		return models.GameRecord{}, err
+	}
+	return game, nil
+}
+
+func (r *AnalysisRepo) GetGameMoves(gameID string) ([]models.Move, error) {
+	var moves []models.Move
+	rows, err := r.db.Query(pg_sql.GetGameMoves, gameID)
+	if err != nil {
+		return []models.Move{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var move models.Move
+		if err := rows.Scan(&move.Move, &move.Fen, &move.MoveOrder, &move.CreatedAt); err != nil {
+			return []models.Move{}, err
+		}
+		moves = append(moves, move)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []models.Move{}, err
+	}
+
+	return moves, nil
+}
+
+func (r *AnalysisRepo) GetCachedAnalysis(fen, engine, engineVersion string, depth int) (models.Evaluation, error) {
+	var evaluation models.Evaluation
+	var mate sql.NullInt64
+	var pv string
+
+	err := r.db.QueryRow(pg_sql.GetCachedAnalysis, fen, engine, engineVersion, depth).
+		Scan(&evaluation.CP, &mate, &evaluation.BestMove, &pv)
+	if err != nil {
+		return models.Evaluation{}, err
+	}
+
+	if mate.Valid {
+		mateIn := int(mate.Int64)
+		evaluation.Mate = &mateIn
+	}
+	evaluation.PV = strings.Fields(pv)
+
+	return evaluation, nil
+}
+
+func (r *AnalysisRepo) SaveAnalysis(fen, engine, engineVersion string, depth int, evaluation models.Evaluation) error {
+	var mate sql.NullInt64
+	if evaluation.Mate != nil {
+		mate = sql.NullInt64{Int64: int64(*evaluation.Mate), Valid: true}
+	}
+
+	_, err := r.db.Exec(pg_sql.SaveAnalysis, fen, engine, engineVersion, depth,
+		evaluation.CP, mate, evaluation.BestMove, strings.Join(evaluation.PV, " "))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// CountTakebacks returns how many takebacks the game has had.
+func (r *AnalysisRepo) CountTakebacks(gameID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(pg_sql.CountTakebacks, gameID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
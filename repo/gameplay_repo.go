@@ -2,7 +2,13 @@ package repo
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/lib/pq"
+
+	"samsungvoicebe/models"
 	"samsungvoicebe/pg_sql"
 )
 
@@ -14,19 +20,267 @@ func NewGameplayRepo(db *sql.DB) *GameplayRepo {
 	return &GameplayRepo{db: db}
 }
 
-func (r *GameplayRepo) GameMove(gameID, fen, move string) error {
-	_, err := r.db.Exec(pg_sql.Move, gameID, fen, move)
+// ErrMoveConflict is returned when a game has moved on, or ended, since the
+// position the moves were played from was read.
+var ErrMoveConflict = errors.New("game changed since it was read")
+
+// uniqueViolation is the Postgres error code for a unique constraint
+// violation.
+const uniqueViolation = "23505"
+
+// AppendMoves stores moves, in order, after the first ply moves of the game
+// and, when outcome is set, records how the game ended. Each move is stored
+// with its CreatedAt, an RFC 3339 time, or the current time when that is
+// empty. It runs in one transaction that locks the game row and checks that
+// exactly ply moves are stored, so concurrent requests cannot interleave
+// their moves. Appending a move also withdraws any draw offer.
+func (r *GameplayRepo) AppendMoves(gameID string, ply int, moves []models.Move, outcome *models.GameOutcome) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	if err := lockActiveGame(tx, gameID, ply); err != nil {
+		return err
+	}
+
+	for i, move := range moves {
+		_, err := tx.Exec(pg_sql.Move, gameID, move.Fen, move.Move, ply+i+1, move.CreatedAt)
+		if err != nil {
+			return storeMoveError(err, ply+i+1)
+		}
+	}
+
+	if len(moves) > 0 {
+		if _, err := tx.Exec(pg_sql.SetDrawOffer, gameID, ""); err != nil {
+			return err
+		}
+	}
+	if outcome != nil {
+		if _, err := tx.Exec(pg_sql.FinishGame, gameID, outcome.Result, outcome.Termination); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// storeMoveError turns a unique violation on storing move moveOrder, which
+// means a concurrent request stored it first, into ErrMoveConflict.
+func storeMoveError(err error, moveOrder int) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: move %d is already stored", ErrMoveConflict, moveOrder)
+	}
+	return err
+}
+
+// lockActiveGame locks the game's row for the rest of tx and checks that the
+// game is still in progress with ply moves stored.
+func lockActiveGame(tx *sql.Tx, gameID string, ply int) error {
+	var lockedID string
+	err := tx.QueryRow(pg_sql.LockActiveGame, gameID).Scan(&lockedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: game is no longer in progress", ErrMoveConflict)
+	}
+	if err != nil {
+		return err
+	}
+
+	var stored int
+	if err := tx.QueryRow(pg_sql.GetPlyCount, gameID).Scan(&stored); err != nil {
+		return err
+	}
+	if stored != ply {
+		return fmt.Errorf("%w: %d moves stored, expected %d", ErrMoveConflict, stored, ply)
+	}
+
 	return nil
 }
 
-func (r *GameplayRepo) CreateGame(userID string) (string, error) {
+// CreateGame stores a new game for game.UserID with the game's settings and
+// returns its id.
+func (r *GameplayRepo) CreateGame(game models.GameRecord) (string, error) {
 	var gameID string
-	err := r.db.QueryRow(pg_sql.CreateGame, userID).Scan(&gameID)
+	err := r.db.QueryRow(pg_sql.CreateGame, game.UserID, game.PlayerColor, game.BotLevel, game.BotElo,
+		game.BotPersonality, game.StartingFen, game.TimeControl).Scan(&gameID)
 	if err != nil {
 		return "", err
 	}
 	return gameID, nil
 }
+
+func (r *GameplayRepo) GetGame(gameID string) (models.GameRecord, error) {
+	var game models.GameRecord
+	err := r.db.QueryRow(pg_sql.GetGame, gameID).Scan(
+		&game.ID, &game.UserID, &game.CreatedAt, &game.PlayerColor, &game.BotLevel,
+		&game.BotElo, &game.BotPersonality, &game.StartingFen, &game.TimeControl,
+		&game.Result, &game.Termination, &game.DrawOfferedBy,
+	)
+	if err != nil {
+		return models.GameRecord{}, err
+	}
+	return game, nil
+}
+
+func (r *GameplayRepo) GetLastMove(gameID string) (models.Move, error) {
+	var move models.Move
+	err := r.db.QueryRow(pg_sql.GetLastMove, gameID).Scan(&move.Move, &move.Fen, &move.MoveOrder)
+	if err != nil {
+		return models.Move{}, err
+	}
+	return move, nil
+}
+
+// GetRecentMoves returns up to limit of the game's latest moves, newest
+// first.
+func (r *GameplayRepo) GetRecentMoves(gameID string, limit int) ([]models.Move, error) {
+	var moves []models.Move
+	rows, err := r.db.Query(pg_sql.GetRecentMoves, gameID, limit)
+	if err != nil {
+		return []models.Move{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var move models.Move
+		if err := rows.Scan(&move.Move, &move.Fen, &move.MoveOrder); err != nil {
+			return []models.Move{}, err
+		}
+		moves = append(moves, move)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []models.Move{}, err
+	}
+
+	return moves, nil
+}
+
+// GetHintLevel returns the highest hint level already given for fen in the
+// game, or 0 when no hint was asked for yet.
+func (r *GameplayRepo) GetHintLevel(gameID, fen string) (int, error) {
+	var level int
+	err := r.db.QueryRow(pg_sql.GetHintLevel, gameID, fen).Scan(&level)
+	if err != nil {
+		return 0, err
+	}
+	return level, nil
+}
+
+func (r *GameplayRepo) SaveHint(gameID, fen string, level int, bestMove string) error {
+	_, err := r.db.Exec(pg_sql.SaveHint, gameID, fen, level, bestMove)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetGameMoves returns every move of the game in move order.
+func (r *GameplayRepo) GetGameMoves(gameID string) ([]models.Move, error) {
+	var moves []models.Move
+	rows, err := r.db.Query(pg_sql.GetGameMoves, gameID)
+	if err != nil {
+		return []models.Move{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var move models.Move
+		if err := rows.Scan(&move.Move, &move.Fen, &move.MoveOrder, &move.CreatedAt); err != nil {
+			return []models.Move{}, err
+		}
+		moves = append(moves, move)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []models.Move{}, err
+	}
+
+	return moves, nil
+}
+
+// GetUnfinishedGames returns the user's games in progress, most recently
+// played first.
+func (r *GameplayRepo) GetUnfinishedGames(userID string) ([]models.UnfinishedGame, error) {
+	var games []models.UnfinishedGame
+	rows, err := r.db.Query(pg_sql.GetUnfinishedGames, userID)
+	if err != nil {
+		return []models.UnfinishedGame{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var game models.UnfinishedGame
+		err := rows.Scan(&game.GameID, &game.CreatedAt, &game.Color, &game.BotLevel, &game.BotElo,
+			&game.Personality, &game.TimeControl, &game.MoveCount, &game.Fen, &game.LastPlayedAt)
+		if err != nil {
+			return []models.UnfinishedGame{}, err
+		}
+		games = append(games, game)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []models.UnfinishedGame{}, err
+	}
+
+	return games, nil
+}
+
+// FinishGame records how the game ended and withdraws any draw offer. A game
+// that is already over is left as it is.
+func (r *GameplayRepo) FinishGame(gameID, result, termination string) error {
+	_, err := r.db.Exec(pg_sql.FinishGame, gameID, result, termination)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetDrawOffer records that color offers a draw; an empty color withdraws
+// the offer.
+func (r *GameplayRepo) SetDrawOffer(gameID, color string) error {
+	_, err := r.db.Exec(pg_sql.SetDrawOffer, gameID, color)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// TakeBack removes the game's moves after takeback.FromMoveOrder and records
+// the takeback, in one transaction. Like AppendMoves it locks the game and
+// fails with ErrMoveConflict unless the taken back moves are the game's last.
+func (r *GameplayRepo) TakeBack(takeback models.Takeback) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveGame(tx, takeback.GameID, takeback.FromMoveOrder+takeback.Plies); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(pg_sql.DeleteMovesAfter, takeback.GameID, takeback.FromMoveOrder); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(pg_sql.SetDrawOffer, takeback.GameID, ""); err != nil {
+		return err
+	}
+	_, err = tx.Exec(pg_sql.SaveTakeback, takeback.GameID, takeback.Plies, takeback.FromMoveOrder,
+		strings.Join(takeback.Moves, " "), takeback.FenBefore, takeback.FenAfter)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *GameplayRepo) DeleteGame(gameID string) error {
+	_, err := r.db.Exec(pg_sql.DeleteGame, gameID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
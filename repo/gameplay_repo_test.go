@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestStoreMoveError(t *testing.T) {
+	other := errors.New("connection reset")
+
+	tests := []struct {
+		name         string
+		err          error
+		wantConflict bool
+	}{
+		{name: "unique violation", err: &pq.Error{Code: uniqueViolation}, wantConflict: true},
+		{name: "wrapped unique violation", err: fmt.Errorf("insert: %w", &pq.Error{Code: uniqueViolation}), wantConflict: true},
+		{name: "other constraint", err: &pq.Error{Code: "23503"}},
+		{name: "other error", err: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := storeMoveError(tt.err, 4)
+			if errors.Is(got, ErrMoveConflict) != tt.wantConflict {
+				t.Fatalf("storeMoveError(%v) = %v, want conflict %v", tt.err, got, tt.wantConflict)
+			}
+			if !tt.wantConflict && got != tt.err {
+				t.Errorf("storeMoveError(%v) = %v, want the error unchanged", tt.err, got)
+			}
+		})
+	}
+}
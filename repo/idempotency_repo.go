@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"samsungvoicebe/models"
+	"samsungvoicebe/pg_sql"
+)
+
+type IdempotencyRepo struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepo(db *sql.DB) *IdempotencyRepo {
+	return &IdempotencyRepo{db: db}
+}
+
+// reserveAttempts bounds how often Reserve tries again when the key it lost
+// to is released or expires before it can be read.
+const reserveAttempts = 3
+
+// Reserve claims record's key for its scope, dropping expired keys first.
+// A key still being handled since before staleBefore is taken over, as the
+// request holding it is assumed lost. When the key is already held it
+// returns false with the stored record.
+func (r *IdempotencyRepo) Reserve(record models.IdempotencyRecord, staleBefore time.Time) (bool, models.IdempotencyRecord, error) {
+	if _, err := r.db.Exec(pg_sql.DeleteExpiredIdempotencyKeys); err != nil {
+		return false, models.IdempotencyRecord{}, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		var id string
+		err := r.db.QueryRow(pg_sql.ReserveIdempotencyKey, record.Key, record.Scope, record.RequestHash,
+			record.ExpiresAt, staleBefore).Scan(&id)
+		if err == nil {
+			return true, models.IdempotencyRecord{}, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return false, models.IdempotencyRecord{}, err
+		}
+
+		var stored models.IdempotencyRecord
+		err = r.db.QueryRow(pg_sql.GetIdempotencyKey, record.Key, record.Scope).Scan(
+			&stored.Key, &stored.Scope, &stored.RequestHash, &stored.StatusCode, &stored.Response, &stored.ExpiresAt,
+		)
+		if err == nil {
+			return false, stored, nil
+		}
+		// No row means the key was released, or deleted as expired, between
+		// the two statements; it is free again, so claim it once more.
+		if !errors.Is(err, sql.ErrNoRows) || attempt == reserveAttempts {
+			return false, models.IdempotencyRecord{}, err
+		}
+	}
+}
+
+// Complete stores the response to replay for a reserved key.
+func (r *IdempotencyRepo) Complete(key, scope string, statusCode int, response string) error {
+	_, err := r.db.Exec(pg_sql.CompleteIdempotencyKey, key, scope, statusCode, response)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Release frees a reserved key that has no response, so the request can be
+// retried.
+func (r *IdempotencyRepo) Release(key, scope string) error {
+	_, err := r.db.Exec(pg_sql.ReleaseIdempotencyKey, key, scope)
+	if err != nil {
+		return err
+	}
+	return nil
+}
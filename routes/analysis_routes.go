@@ -12,5 +12,7 @@ func AnalysisRoutes(router *gin.RouterGroup, cfg *config.Config, service *servic
 
 	router.GET("/:user_id/games", analysisController.GetGameHistoryList)
 	router.GET("/game/:game_id/move/:move_order", analysisController.GetAnalyzedMoveByOrder)
+	router.GET("/game/:game_id/pgn", analysisController.GetGamePGN)
+	router.GET("/game/:game_id/report", analysisController.GetGameReport)
 	router.POST("/fen-from-image", analysisController.GetFenFromPicture)
 }
@@ -3,12 +3,17 @@ package routes
 import (
 	"samsungvoicebe/config"
 	"samsungvoicebe/controllers"
+	"samsungvoicebe/llm"
+	"samsungvoicebe/models"
+	"samsungvoicebe/pending"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ChessRoutes(router *gin.RouterGroup, cfg *config.Config) {
-	chessController := controllers.NewChessController(cfg)
+func ChessRoutes(router *gin.RouterGroup, cfg *config.Config, llmClient llm.LLM, voiceChoices *pending.Store[models.PendingChoice]) {
+	chessController := controllers.NewChessController(cfg, llmClient, voiceChoices)
 
 	router.POST("/ai", chessController.PlayChess)
+	router.POST("/ai/disambiguate", chessController.Disambiguate)
+	router.POST("/ai/confirm", chessController.ConfirmMove)
 }
@@ -7,13 +7,25 @@ import (
 	"samsungvoicebe/services"
 )
 
-func GameplayRoutes(router *gin.RouterGroup, cfg *config.Config, service *services.GameplayService) {
+func GameplayRoutes(router *gin.RouterGroup, cfg *config.Config, service *services.GameplayService, idempotency gin.HandlerFunc) {
 	gameplayController := controllers.NewGameplayController(cfg, service)
 
-	router.POST("/game/:game_id/move", gameplayController.PlayerMove)
-	router.POST("/:user_id/game", gameplayController.CreateGame)
+	router.GET("/game/:game_id", gameplayController.GetGame)
+	router.POST("/game/:game_id/move", idempotency, gameplayController.PlayerMove)
+	router.POST("/game/:game_id/undo", idempotency, gameplayController.Undo)
+	router.POST("/game/:game_id/resign", idempotency, gameplayController.Resign)
+	router.POST("/game/:game_id/draw/offer", gameplayController.OfferDraw)
+	router.POST("/game/:game_id/draw/accept", gameplayController.AcceptDraw)
+	router.GET("/:user_id/games/unfinished", gameplayController.GetUnfinishedGames)
+	router.POST("/:user_id/game", idempotency, gameplayController.CreateGame)
+	router.POST("/:user_id/pgn", gameplayController.ImportPGN)
 	router.POST("/hint", gameplayController.GetHint)
 	router.POST("/move-by-voice", gameplayController.PlayerMoveByVoiceTranscription)
-	router.POST("/game/move", gameplayController.PlayerMove)
+	router.POST("/move-by-voice/disambiguate", gameplayController.ResolveVoiceDisambiguation)
+	router.POST("/game/move", idempotency, gameplayController.PlayerMove)
+	router.POST("/board/describe", gameplayController.DescribeBoard)
+	router.POST("/board/warnings", gameplayController.GetWarnings)
+	router.GET("/bot-levels", gameplayController.GetBotLevels)
+	router.GET("/bot-personalities", gameplayController.GetBotPersonalities)
 
 }
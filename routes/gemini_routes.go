@@ -3,12 +3,13 @@ package routes
 import (
 	"samsungvoicebe/config"
 	"samsungvoicebe/controllers"
+	"samsungvoicebe/llm"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ChatRoutes(router *gin.RouterGroup, cfg *config.Config) {
-	chatController := controllers.NewChatController(cfg)
+func ChatRoutes(router *gin.RouterGroup, cfg *config.Config, llmClient llm.LLM) {
+	chatController := controllers.NewChatController(cfg, llmClient)
 
 	router.POST("/gemini", chatController.Chat)
 }
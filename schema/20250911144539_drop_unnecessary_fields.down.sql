@@ -1,8 +1,21 @@
+-- The columns come back as the create migrations defined them. Existing
+-- games get their move count, an unknown result and no end type so the
+-- NOT NULL constraints hold.
 ALTER TABLE public.games
-    ADD COLUMN move_amount integer,
-    ADD COLUMN end_type varchar(50),
-    ADD COLUMN result varchar(50);
+    ADD COLUMN move_amount INT,
+    ADD COLUMN end_type VARCHAR(50),
+    ADD COLUMN result VARCHAR(10);
 
-ALTER TABLE public.users ADD COLUMN username varchar(255);
+UPDATE public.games
+    SET move_amount = (SELECT COUNT(*) FROM public.moves WHERE moves.game_id = games.id),
+        end_type = '',
+        result = '*';
 
-ALTER TABLE public.moves ADD COLUMN user_id uuid REFERENCES public.users(id);
\ No newline at end of file
+ALTER TABLE public.games
+    ALTER COLUMN move_amount SET NOT NULL,
+    ALTER COLUMN end_type SET NOT NULL,
+    ALTER COLUMN result SET NOT NULL;
+
+ALTER TABLE public.users ADD COLUMN username VARCHAR(150) UNIQUE;
+
+ALTER TABLE public.moves ADD COLUMN user_id UUID REFERENCES public.users(id) ON DELETE CASCADE;
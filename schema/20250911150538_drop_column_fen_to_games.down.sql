@@ -1 +1,12 @@
-ALTER TABLE public.games ADD COLUMNT fen varchar(255);
\ No newline at end of file
+-- Existing games get the position of their latest move, or the standard
+-- starting position when nothing has been played, so fen can be NOT NULL
+-- again.
+ALTER TABLE public.games ADD COLUMN fen VARCHAR(500);
+
+UPDATE public.games
+    SET fen = COALESCE(
+        (SELECT moves.fen FROM public.moves WHERE moves.game_id = games.id ORDER BY moves.move_order DESC LIMIT 1),
+        'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1'
+    );
+
+ALTER TABLE public.games ALTER COLUMN fen SET NOT NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE public.analyses (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY NOT NULL,
+    fen VARCHAR(100) NOT NULL,
+    engine VARCHAR(50) NOT NULL,
+    engine_version VARCHAR(100) NOT NULL,
+    depth INT NOT NULL,
+    score_cp INT NOT NULL,
+    score_mate INT,
+    best_move VARCHAR(10) NOT NULL,
+    pv TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT analyses_fen_engine_depth_key UNIQUE (fen, engine, depth)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE public.hints (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY NOT NULL,
+    game_id UUID NOT NULL REFERENCES public.games(id) ON DELETE CASCADE,
+    fen VARCHAR(100) NOT NULL,
+    level INT NOT NULL,
+    best_move VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX hints_game_id_fen_idx ON public.hints (game_id, fen);
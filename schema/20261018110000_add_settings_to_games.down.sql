@@ -0,0 +1,7 @@
+ALTER TABLE public.games
+    DROP COLUMN player_color,
+    DROP COLUMN bot_level,
+    DROP COLUMN bot_elo,
+    DROP COLUMN bot_personality,
+    DROP COLUMN starting_fen,
+    DROP COLUMN time_control;
@@ -0,0 +1,7 @@
+ALTER TABLE public.games
+    ADD COLUMN player_color VARCHAR(5) NOT NULL DEFAULT 'white',
+    ADD COLUMN bot_level VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN bot_elo INT NOT NULL DEFAULT 0,
+    ADD COLUMN bot_personality VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN starting_fen VARCHAR(100) NOT NULL DEFAULT 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1',
+    ADD COLUMN time_control VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+ALTER TABLE public.games
+    DROP COLUMN result,
+    DROP COLUMN termination,
+    DROP COLUMN draw_offered_by,
+    DROP COLUMN ended_at;
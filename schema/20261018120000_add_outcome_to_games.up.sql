@@ -0,0 +1,5 @@
+ALTER TABLE public.games
+    ADD COLUMN result VARCHAR(10) NOT NULL DEFAULT '*',
+    ADD COLUMN termination VARCHAR(30) NOT NULL DEFAULT '',
+    ADD COLUMN draw_offered_by VARCHAR(5) NOT NULL DEFAULT '',
+    ADD COLUMN ended_at TIMESTAMP WITH TIME ZONE;
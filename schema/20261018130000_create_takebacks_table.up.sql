@@ -0,0 +1,12 @@
+CREATE TABLE public.takebacks (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY NOT NULL,
+    game_id UUID NOT NULL REFERENCES public.games(id) ON DELETE CASCADE,
+    plies INT NOT NULL,
+    from_move_order INT NOT NULL,
+    moves VARCHAR(500) NOT NULL,
+    fen_before VARCHAR(100) NOT NULL,
+    fen_after VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX takebacks_game_id_idx ON public.takebacks (game_id);
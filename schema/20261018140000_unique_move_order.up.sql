@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS moves_set_order ON public.moves;
+DROP FUNCTION IF EXISTS set_move_order();
+
+-- Concurrent inserts through the trigger could give two moves of a game the
+-- same move_order; renumber each game in the order its moves were stored.
+UPDATE public.moves
+    SET move_order = numbered.move_order
+    FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY game_id ORDER BY move_order, created_at, id) AS move_order
+            FROM public.moves
+    ) AS numbered
+    WHERE moves.id = numbered.id AND moves.move_order <> numbered.move_order;
+
+ALTER TABLE public.moves
+    ADD CONSTRAINT moves_game_id_move_order_key UNIQUE (game_id, move_order);
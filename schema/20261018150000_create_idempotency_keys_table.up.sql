@@ -0,0 +1,13 @@
+CREATE TABLE public.idempotency_keys (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    scope VARCHAR(500) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INT,
+    response TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT idempotency_keys_key_scope_key UNIQUE (idempotency_key, scope)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON public.idempotency_keys (expires_at);
@@ -0,0 +1,9 @@
+// Package schema embeds the database migrations so the binary can apply them
+// itself. Each migration is a pair of files named
+// YYYYMMDDHHMMSS_name.up.sql and YYYYMMDDHHMMSS_name.down.sql.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var Migrations embed.FS
@@ -0,0 +1,156 @@
+package search
+
+import "github.com/notnil/chess"
+
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   100,
+	chess.Knight: 320,
+	chess.Bishop: 330,
+	chess.Rook:   500,
+	chess.Queen:  900,
+	chess.King:   0,
+}
+
+// Piece-square tables are written from white's side with rank 8 on the first
+// row, so they read like a board diagram.
+var (
+	pawnTable = [64]int{
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	}
+
+	knightTable = [64]int{
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	}
+
+	bishopTable = [64]int{
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	}
+
+	rookTable = [64]int{
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0,
+	}
+
+	queenTable = [64]int{
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20,
+	}
+
+	kingMiddlegameTable = [64]int{
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20,
+	}
+
+	kingEndgameTable = [64]int{
+		-50, -40, -30, -20, -20, -30, -40, -50,
+		-30, -20, -10, 0, 0, -10, -20, -30,
+		-30, -10, 20, 30, 30, 20, -10, -30,
+		-30, -10, 30, 40, 40, 30, -10, -30,
+		-30, -10, 30, 40, 40, 30, -10, -30,
+		-30, -10, 20, 30, 30, 20, -10, -30,
+		-30, -30, 0, 0, 0, 0, -30, -30,
+		-50, -30, -30, -30, -30, -30, -30, -50,
+	}
+)
+
+// endgameMaterial is the non-pawn material per side below which the king
+// switches to its endgame table and heads for the centre.
+const endgameMaterial = 1300
+
+// Evaluate scores a position in centipawns from the point of view of the
+// side to move: material plus piece-square bonuses.
+func Evaluate(position *chess.Position) int {
+	squares := position.Board().SquareMap()
+
+	nonPawnMaterial := map[chess.Color]int{}
+	for _, piece := range squares {
+		if piece.Type() != chess.Pawn && piece.Type() != chess.King {
+			nonPawnMaterial[piece.Color()] += pieceValues[piece.Type()]
+		}
+	}
+	endgame := nonPawnMaterial[chess.White] <= endgameMaterial && nonPawnMaterial[chess.Black] <= endgameMaterial
+
+	score := 0
+	for square, piece := range squares {
+		value := pieceValues[piece.Type()] + squareBonus(piece, square, endgame)
+		if piece.Color() == chess.White {
+			score += value
+		} else {
+			score -= value
+		}
+	}
+
+	if position.Turn() == chess.Black {
+		return -score
+	}
+	return score
+}
+
+func squareBonus(piece chess.Piece, square chess.Square, endgame bool) int {
+	file := int(square.File())
+	rank := int(square.Rank())
+
+	// Tables start at rank 8; black reads them mirrored.
+	index := (7-rank)*8 + file
+	if piece.Color() == chess.Black {
+		index = rank*8 + file
+	}
+
+	switch piece.Type() {
+	case chess.Pawn:
+		return pawnTable[index]
+	case chess.Knight:
+		return knightTable[index]
+	case chess.Bishop:
+		return bishopTable[index]
+	case chess.Rook:
+		return rookTable[index]
+	case chess.Queen:
+		return queenTable[index]
+	case chess.King:
+		if endgame {
+			return kingEndgameTable[index]
+		}
+		return kingMiddlegameTable[index]
+	}
+	return 0
+}
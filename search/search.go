@@ -0,0 +1,309 @@
+// Package search is a small alpha-beta chess searcher used as a fallback
+// opponent when no UCI engine is available.
+package search
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/notnil/chess"
+)
+
+var ErrNoMoves = errors.New("position has no legal moves")
+
+const (
+	// MateScore is the score of delivering mate on the next move; mates
+	// further away score one point less per ply.
+	MateScore = 100000
+
+	infinity        = MateScore + 1
+	mateThreshold   = MateScore - 1000
+	quiescenceDepth = 8
+
+	// randomMargin is how many centipawns below the best move a move may
+	// score and still be picked when RandomFactor is 1.
+	randomMargin = 200
+
+	ctxCheckInterval = 1024
+)
+
+type Options struct {
+	// Depth is the number of plies searched before quiescence; values below
+	// one search a single ply.
+	Depth int
+	// RandomFactor between 0 and 1 widens the set of moves the result is
+	// picked from to those within RandomFactor*200 centipawns of the best.
+	RandomFactor float64
+	// Window, when larger, replaces RandomFactor's margin of centipawns below
+	// the best move within which a move may still be picked.
+	Window int
+	// Bias, when set, turns the random pick into a choice: the move with the
+	// highest score plus bias within the margin is played.
+	Bias func(move *chess.Move) int
+}
+
+type Result struct {
+	Move  *chess.Move
+	Score int
+	Depth int
+	Nodes int
+}
+
+type scoredMove struct {
+	move  *chess.Move
+	score int
+}
+
+type searcher struct {
+	ctx     context.Context
+	nodes   int
+	stopped bool
+}
+
+// BestMove searches the position with iterative deepening up to opts.Depth.
+// When ctx is cancelled the result of the deepest completed iteration is
+// returned; scores are from the side to move's point of view.
+func BestMove(ctx context.Context, position *chess.Position, opts Options) (Result, error) {
+	moves := position.ValidMoves()
+	if len(moves) == 0 {
+		return Result{}, ErrNoMoves
+	}
+
+	depth := opts.Depth
+	if depth < 1 {
+		depth = 1
+	}
+
+	margin := 0
+	if opts.RandomFactor > 0 {
+		margin = int(opts.RandomFactor * randomMargin)
+	}
+	if opts.Window > margin {
+		margin = opts.Window
+	}
+
+	s := &searcher{ctx: ctx}
+	orderMoves(position, moves)
+
+	var completed []scoredMove
+	completedDepth := 0
+	for d := 1; d <= depth; d++ {
+		scored := s.searchRoot(position, moves, d, margin)
+		if s.stopped {
+			break
+		}
+
+		completed = scored
+		completedDepth = d
+
+		// Search the previous best line first next iteration.
+		for i, sm := range scored {
+			moves[i] = sm.move
+		}
+
+		if abs(scored[0].score) >= mateThreshold {
+			break
+		}
+	}
+
+	if completed == nil {
+		return Result{Move: moves[0], Nodes: s.nodes}, nil
+	}
+
+	pick := pickMove(completed, margin, opts.Bias)
+	return Result{
+		Move:  pick.move,
+		Score: pick.score,
+		Depth: completedDepth,
+		Nodes: s.nodes,
+	}, nil
+}
+
+// searchRoot scores every root move and returns them best first. Moves are
+// searched against a window lowered by margin so every move close enough to
+// be picked gets an exact score.
+func (s *searcher) searchRoot(position *chess.Position, moves []*chess.Move, depth, margin int) []scoredMove {
+	scored := make([]scoredMove, 0, len(moves))
+	best := -infinity
+
+	for _, move := range moves {
+		alpha := -infinity
+		if best > -infinity {
+			alpha = best - margin - 1
+		}
+
+		score := -s.negamax(position.Update(move), depth-1, -infinity, -alpha, 1)
+		if s.stopped {
+			return nil
+		}
+
+		scored = append(scored, scoredMove{move: move, score: score})
+		if score > best {
+			best = score
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	return scored
+}
+
+func (s *searcher) negamax(position *chess.Position, depth, alpha, beta, ply int) int {
+	if s.checkStop() {
+		return 0
+	}
+
+	moves := position.ValidMoves()
+	if len(moves) == 0 {
+		return terminalScore(position, ply)
+	}
+	if position.HalfMoveClock() >= 100 {
+		return 0
+	}
+
+	if depth <= 0 {
+		return s.quiescence(position, moves, alpha, beta, ply, quiescenceDepth)
+	}
+
+	orderMoves(position, moves)
+	for _, move := range moves {
+		score := -s.negamax(position.Update(move), depth-1, -beta, -alpha, ply+1)
+		if s.stopped {
+			return 0
+		}
+		if score >= beta {
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	return alpha
+}
+
+// quiescence keeps searching captures and promotions until the position is
+// quiet so the static evaluation is never taken in the middle of an exchange.
+func (s *searcher) quiescence(position *chess.Position, moves []*chess.Move, alpha, beta, ply, depth int) int {
+	standPat := Evaluate(position)
+	if standPat >= beta {
+		return beta
+	}
+	if standPat > alpha {
+		alpha = standPat
+	}
+	if depth == 0 {
+		return alpha
+	}
+
+	orderMoves(position, moves)
+	for _, move := range moves {
+		if !isTactical(move) {
+			continue
+		}
+
+		child := position.Update(move)
+		childMoves := child.ValidMoves()
+
+		var score int
+		if len(childMoves) == 0 {
+			score = -terminalScore(child, ply+1)
+		} else {
+			score = -s.quiescence(child, childMoves, -beta, -alpha, ply+1, depth-1)
+		}
+		if s.stopped {
+			return 0
+		}
+
+		if score >= beta {
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	return alpha
+}
+
+func (s *searcher) checkStop() bool {
+	s.nodes++
+	if s.nodes%ctxCheckInterval == 0 && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+	return s.stopped
+}
+
+// terminalScore scores a position without legal moves: mated (shorter mates
+// score higher for the winner) or stalemate.
+func terminalScore(position *chess.Position, ply int) int {
+	if position.Status() == chess.Checkmate {
+		return -(MateScore - ply)
+	}
+	return 0
+}
+
+func isTactical(move *chess.Move) bool {
+	return move.HasTag(chess.Capture) || move.HasTag(chess.EnPassant) || move.Promo() != chess.NoPieceType
+}
+
+// orderMoves sorts captures (most valuable victim, least valuable attacker)
+// and promotions ahead of checks and quiet moves, which improves cut-offs.
+func orderMoves(position *chess.Position, moves []*chess.Move) {
+	board := position.Board()
+	priority := func(move *chess.Move) int {
+		p := 0
+		if move.HasTag(chess.Capture) {
+			p += 10*pieceValues[board.Piece(move.S2()).Type()] - pieceValues[board.Piece(move.S1()).Type()]/10
+		}
+		if move.HasTag(chess.EnPassant) {
+			p += 10 * pieceValues[chess.Pawn]
+		}
+		if move.Promo() != chess.NoPieceType {
+			p += 10 * pieceValues[move.Promo()]
+		}
+		if move.HasTag(chess.Check) {
+			p += 50
+		}
+		return p
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return priority(moves[i]) > priority(moves[j])
+	})
+}
+
+// pickMove chooses among the moves scoring within margin of the best, at
+// random or by bias when one is given. Forced mates are never given away.
+func pickMove(scored []scoredMove, margin int, bias func(move *chess.Move) int) scoredMove {
+	best := scored[0]
+	if margin <= 0 || abs(best.score) >= mateThreshold {
+		return best
+	}
+
+	candidates := 1
+	for candidates < len(scored) && scored[candidates].score >= best.score-margin {
+		candidates++
+	}
+	if bias == nil {
+		return scored[rand.Intn(candidates)]
+	}
+
+	pick, pickValue := best, best.score+bias(best.move)
+	for _, sm := range scored[1:candidates] {
+		if value := sm.score + bias(sm.move); value > pickValue {
+			pick, pickValue = sm, value
+		}
+	}
+	return pick
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
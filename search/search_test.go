@@ -0,0 +1,165 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+const startFen = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+func position(t *testing.T, fen string) *chess.Position {
+	t.Helper()
+	fenOption, err := chess.FEN(fen)
+	if err != nil {
+		t.Fatalf("chess.FEN(%q): %v", fen, err)
+	}
+	return chess.NewGame(fenOption).Position()
+}
+
+func TestBestMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		opts     Options
+		want     string
+		wantMate bool
+	}{
+		{name: "back rank mate", fen: "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", opts: Options{Depth: 2}, want: "a1a8", wantMate: true},
+		{name: "black mates too", fen: "r5k1/8/8/8/8/8/5PPP/6K1 b - - 0 1", opts: Options{Depth: 2}, want: "a8a1", wantMate: true},
+		{name: "wins a hanging queen", fen: "4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1", opts: Options{Depth: 2}, want: "d1d5"},
+		{name: "depth below one searches a ply", fen: "4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1", want: "d1d5"},
+		{name: "randomness never gives away mate", fen: "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", opts: Options{Depth: 2, RandomFactor: 1}, want: "a1a8", wantMate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := BestMove(context.Background(), position(t, tt.fen), tt.opts)
+			if err != nil {
+				t.Fatalf("BestMove: %v", err)
+			}
+			if got := result.Move.String(); got != tt.want {
+				t.Errorf("BestMove move = %s, want %s", got, tt.want)
+			}
+			if mate := result.Score >= mateThreshold; mate != tt.wantMate {
+				t.Errorf("BestMove score = %d, mate %v, want mate %v", result.Score, mate, tt.wantMate)
+			}
+		})
+	}
+}
+
+func TestBestMoveWithoutMoves(t *testing.T) {
+	for name, fen := range map[string]string{
+		"checkmate": "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3",
+		"stalemate": "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := BestMove(context.Background(), position(t, fen), Options{Depth: 2}); !errors.Is(err, ErrNoMoves) {
+				t.Errorf("BestMove = %v, want ErrNoMoves", err)
+			}
+		})
+	}
+}
+
+func TestBestMoveCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := BestMove(ctx, position(t, startFen), Options{Depth: 20})
+	if err != nil {
+		t.Fatalf("BestMove: %v", err)
+	}
+	if result.Move == nil {
+		t.Fatal("BestMove returned no move after cancellation")
+	}
+	if result.Depth >= 20 {
+		t.Errorf("BestMove completed depth %d despite the cancelled context", result.Depth)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want func(score int) bool
+	}{
+		{name: "start is level", fen: startFen, want: func(score int) bool { return score == 0 }},
+		{name: "extra queen for the side to move", fen: "4k3/8/8/8/8/8/8/3QK3 w - - 0 1", want: func(score int) bool { return score > 800 }},
+		{name: "extra queen for the other side", fen: "4k3/8/8/8/8/8/8/3QK3 b - - 0 1", want: func(score int) bool { return score < -800 }},
+		{name: "mirrored position is level", fen: "4k3/4p3/8/8/8/8/4P3/4K3 w - - 0 1", want: func(score int) bool { return score == 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if score := Evaluate(position(t, tt.fen)); !tt.want(score) {
+				t.Errorf("Evaluate(%q) = %d", tt.fen, score)
+			}
+		})
+	}
+}
+
+func TestOrderMoves(t *testing.T) {
+	// The rook can take the queen on a5 or the pawn on h1.
+	pos := position(t, "4k3/8/8/q7/8/8/4K3/R6p w - - 0 1")
+	moves := pos.ValidMoves()
+	orderMoves(pos, moves)
+
+	var got []string
+	for _, move := range moves[:2] {
+		got = append(got, move.String())
+	}
+	if got[0] != "a1a5" || got[1] != "a1h1" {
+		t.Errorf("orderMoves put %v first, want [a1a5 a1h1]", got)
+	}
+}
+
+func TestPickMove(t *testing.T) {
+	moves := position(t, startFen).ValidMoves()
+	scored := []scoredMove{
+		{move: moves[0], score: 50},
+		{move: moves[1], score: 40},
+		{move: moves[2], score: 10},
+	}
+	preferThird := func(move *chess.Move) int {
+		if move == moves[2] {
+			return 1000
+		}
+		return 0
+	}
+	preferSecond := func(move *chess.Move) int {
+		if move == moves[1] {
+			return 1000
+		}
+		return 0
+	}
+
+	tests := []struct {
+		name   string
+		scored []scoredMove
+		margin int
+		bias   func(move *chess.Move) int
+		want   *chess.Move
+	}{
+		{name: "no margin takes the best", scored: scored, margin: 0, bias: preferThird, want: moves[0]},
+		{name: "bias within the margin", scored: scored, margin: 15, bias: preferSecond, want: moves[1]},
+		{name: "bias outside the margin", scored: scored, margin: 15, bias: preferThird, want: moves[0]},
+		{name: "wide margin", scored: scored, margin: 100, bias: preferThird, want: moves[2]},
+		{
+			name:   "mate is kept",
+			scored: []scoredMove{{move: moves[0], score: MateScore - 1}, {move: moves[1], score: MateScore - 3}},
+			margin: 100,
+			bias:   preferSecond,
+			want:   moves[0],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pickMove(tt.scored, tt.margin, tt.bias).move; got != tt.want {
+				t.Errorf("pickMove() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,39 +1,68 @@
 package services
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/notnil/chess"
-	"github.com/notnil/chess/uci"
+	"samsungvoicebe/difficulty"
+	"samsungvoicebe/engine"
 	"samsungvoicebe/helper"
+	"samsungvoicebe/llm"
 	"samsungvoicebe/models"
-	"samsungvoicebe/repo"
+	"samsungvoicebe/style"
 )
 
-type AnalysisService struct {
-	analysisRepo *repo.AnalysisRepo
-}
+const (
+	stockfishSearchTimeout = 30 * time.Second
+	gameReportTimeout      = 3 * time.Minute
 
-func NewAnalysisService(analysisRepo *repo.AnalysisRepo) *AnalysisService {
-	return &AnalysisService{analysisRepo: analysisRepo}
+	// personalityCandidates is how many lines the engine is asked for when a
+	// personality picks between them.
+	personalityCandidates = 5
+)
+
+// AnalysisStore reads finished games and keeps the analyses cache; in
+// production it is repo.AnalysisRepo.
+type AnalysisStore interface {
+	GetGameHistoryList(userID string) ([]models.Game, error)
+	GetGame(gameID string) (models.GameRecord, error)
+	GetGameMoves(gameID string) ([]models.Move, error)
+	GetMoveByOrder(moveOrder int, gameID string) (models.Move, error)
+	CountTakebacks(gameID string) (int, error)
+	GetCachedAnalysis(fen, engine, engineVersion string, depth int) (models.Evaluation, error)
+	SaveAnalysis(fen, engine, engineVersion string, depth int, evaluation models.Evaluation) error
 }
 
-func (a *AnalysisService) StockfishAnalyze(fen string, botLevel string) (models.StockfishAnalysisResult, error) {
-	var analysisResult models.StockfishAnalysisResult
+type AnalysisService struct {
+	analysisRepo AnalysisStore
+	enginePool   *engine.Pool
+	llm          llm.LLM
+	botLevels    *difficulty.Levels
+}
 
-	engine, err := uci.New("stockfish")
-	if err != nil {
-		err = fmt.Errorf("AnalysisService-StockfishAnalyze-uci.New: %w", err)
-		return models.StockfishAnalysisResult{}, err
+func NewAnalysisService(analysisRepo AnalysisStore, enginePool *engine.Pool, llmClient llm.LLM, botLevels *difficulty.Levels) *AnalysisService {
+	return &AnalysisService{
+		analysisRepo: analysisRepo,
+		enginePool:   enginePool,
+		llm:          llmClient,
+		botLevels:    botLevels,
 	}
-	defer engine.Close()
+}
 
-	err = engine.Run(uci.CmdUCI, uci.CmdIsReady)
-	if err != nil {
-		err = fmt.Errorf("AnalysisService-StockfishAnalyze-engine.Run-uci.CmdUCI-uci.CmdIsReady: %w", err)
-		return models.StockfishAnalysisResult{}, err
-	}
+// StockfishAnalyze searches the position with a pooled engine playing at the
+// profile's strength and returns the chosen move together with the FEN after
+// playing it. With a personality the engine reports several lines and the
+// personality picks between those close to the engine's own choice. gameID
+// may be empty; when set, the engine is reset with ucinewgame if it last
+// searched another game.
+func (a *AnalysisService) StockfishAnalyze(gameID, fen string, profile models.BotProfile, personality models.Personality) (models.StockfishAnalysisResult, error) {
+	var analysisResult models.StockfishAnalysisResult
 
 	position, err := chess.FEN(fen)
 	if err != nil {
@@ -43,33 +72,47 @@ func (a *AnalysisService) StockfishAnalyze(fen string, botLevel string) (models.
 
 	game := chess.NewGame(position)
 
-	err = engine.Run(uci.CmdPosition{Position: game.Position()})
-	if err != nil {
-		err = fmt.Errorf("AnalysisService-StockfishAnalyze-engine.Run-uci.CmdPosition: %w", err)
-		return models.StockfishAnalysisResult{}, err
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), stockfishSearchTimeout)
+	defer cancel()
 
-	var depth int
-	switch botLevel {
-	case "easy":
-		depth = models.BotLevelEasy
-	case "medium":
-		depth = models.BotLevelMedium
-	case "hard":
-		depth = models.BotLevelhard
-	default:
-		depth = models.BotLevelMedium
+	multiPV := 1
+	if personality.Name != "" {
+		multiPV = personalityCandidates
 	}
 
-	searchBestMove := uci.CmdGo{Depth: depth}
+	var searchResult engine.SearchResult
+	err = a.enginePool.Do(ctx, func(e *engine.Engine) error {
+		if err := e.NewGame(ctx, gameID); err != nil {
+			return err
+		}
+		if err := e.SetOptions(ctx, difficulty.EngineOptions(profile)); err != nil {
+			return err
+		}
+		searchResult, err = e.Search(ctx, engine.SearchRequest{
+			Fen:      game.FEN(),
+			Depth:    profile.Depth,
+			MoveTime: time.Duration(profile.MoveTimeMs) * time.Millisecond,
+			Nodes:    profile.Nodes,
+			MultiPV:  multiPV,
+		})
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-StockfishAnalyze-enginePool.Do: %w", err)
+		return models.StockfishAnalysisResult{}, err
+	}
 
-	err = engine.Run(searchBestMove)
+	bestMove, err := helper.DecodeMove(game.Position(), searchResult.BestMove)
 	if err != nil {
-		err = fmt.Errorf("AnalysisService-StockfishAnalyze-engine.Run-searchBestMove: %w", err)
+		err = fmt.Errorf("AnalysisService-StockfishAnalyze-DecodeMove: %w", err)
 		return models.StockfishAnalysisResult{}, err
 	}
 
-	bestMove := engine.SearchResults().BestMove
+	if personality.Name != "" {
+		if move := styledMove(game.Position(), searchResult, bestMove, personality); move != nil {
+			bestMove = move
+		}
+	}
 
 	err = game.Move(bestMove)
 	if err != nil {
@@ -81,10 +124,54 @@ func (a *AnalysisService) StockfishAnalyze(fen string, botLevel string) (models.
 		BestMove: bestMove.String(),
 		Fen:      game.FEN(),
 	}
+	if len(searchResult.Lines) > 0 {
+		score := sideToMoveScore(searchResult.Lines[0].Score)
+		analysisResult.Score = &score
+	}
 
 	return analysisResult, nil
 }
 
+// styledMove lets personality choose among the engine's lines, measured
+// against the line of the move the engine itself would play so a weakened
+// engine is not made stronger. It returns nil when the lines are unusable.
+func styledMove(position *chess.Position, result engine.SearchResult, bestMove *chess.Move, personality models.Personality) *chess.Move {
+	if len(result.Lines) == 0 {
+		return nil
+	}
+
+	reference := sideToMoveScore(result.Lines[0].Score)
+	var candidates []style.Candidate
+	for _, line := range result.Lines {
+		if len(line.PV) == 0 {
+			continue
+		}
+		move, err := helper.DecodeMove(position, line.PV[0])
+		if err != nil {
+			continue
+		}
+		score := sideToMoveScore(line.Score)
+		if move.String() == bestMove.String() {
+			reference = score
+		}
+		candidates = append(candidates, style.Candidate{Move: move, Score: score})
+	}
+
+	return style.Choose(position, candidates, reference, personality)
+}
+
+// sideToMoveScore turns an engine score into centipawns, with mates worth
+// more the sooner they come.
+func sideToMoveScore(score engine.Score) int {
+	if !score.IsMate {
+		return score.CP
+	}
+	if score.Mate > 0 {
+		return models.MateScore - score.Mate
+	}
+	return -models.MateScore - score.Mate
+}
+
 func (a *AnalysisService) GetGameHistoryList(userID string) ([]models.Game, error) {
 	games, err := a.analysisRepo.GetGameHistoryList(userID)
 	if err != nil {
@@ -109,24 +196,343 @@ func (a *AnalysisService) GetAnalyzedMoveByOrder(moveOrder int, gameID string) (
 		Fen:  move.Fen,
 	}
 
-	stockfishResult, err := a.StockfishAnalyze(move.Fen, "hard")
+	evaluation, err := a.AnalyzePosition(gameID, move.Fen, models.MoveAnalysisDepth)
 	if err != nil {
-		err = fmt.Errorf("AnalysisService-GetAnalyzedMoveByOrder-StockfishAnalyze: %w", err)
+		err = fmt.Errorf("AnalysisService-GetAnalyzedMoveByOrder-AnalyzePosition: %w", err)
 		return models.MoveAnalysis{}, err
 	}
 
-	analyzedMove.BestMove = stockfishResult.BestMove
+	analyzedMove.BestMove = evaluation.BestMove
+	analyzedMove.Evaluation = &evaluation
 
 	return analyzedMove, nil
 }
 
+// AnalyzePosition evaluates a position from white's point of view, serving it
+// from the analyses cache when an analysis at least as deep exists for the
+// running engine version.
+func (a *AnalysisService) AnalyzePosition(gameID, fen string, depth int) (models.Evaluation, error) {
+	fenOption, err := chess.FEN(fen)
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-AnalyzePosition-chess.FEN: %w", err)
+		return models.Evaluation{}, err
+	}
+	position := chess.NewGame(fenOption).Position()
+
+	if evaluation, ok := terminalEvaluation(position); ok {
+		return evaluation, nil
+	}
+	if evaluation, ok := a.cachedEvaluation(a.enginePool.EngineName(), position, depth); ok {
+		return evaluation, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stockfishSearchTimeout)
+	defer cancel()
+
+	var evaluation models.Evaluation
+	err = a.enginePool.Do(ctx, func(e *engine.Engine) error {
+		if err := e.NewGame(ctx, gameID); err != nil {
+			return err
+		}
+		evaluation, err = a.evaluatePosition(ctx, e, position, depth)
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-AnalyzePosition-enginePool.Do: %w", err)
+		return models.Evaluation{}, err
+	}
+
+	return evaluation, nil
+}
+
 func (a *AnalysisService) GetFenFromPicture(imageFile []byte) (string, error) {
-	fen, err := helper.AnalyzePictureWithGemini(imageFile, models.GetFenFromPicturePrompt)
+	fen, err := a.llm.GenerateWithImage(context.Background(), llm.TaskVision, models.GetFenFromPicturePrompt, llm.Image{Data: imageFile})
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-GetFenFromPicture-GenerateWithImage: %w", err)
+		return "", err
+	}
+
 	fen = strings.TrimSpace(fen)
-	if err != nil || fen == models.InvalidImage {
-		err = fmt.Errorf("AnalysisService-GetFenFromPicture-AnalyzePictureWithGemini: %w", err)
+	if fen == models.InvalidImage {
+		err = fmt.Errorf("AnalysisService-GetFenFromPicture-GenerateWithImage: no chessboard found in the image")
 		return "", err
 	}
 
 	return fen, nil
 }
+
+// ExportPGN rebuilds a stored game from its moves and renders it as PGN with
+// the Seven Tag Roster, plus the time control and, for games that did not
+// start from the standard position, the SetUp and FEN tags.
+func (a *AnalysisService) ExportPGN(gameID string) (string, error) {
+	gameRecord, err := a.analysisRepo.GetGame(gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrGameNotFound
+	}
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-ExportPGN-GetGame: %w", err)
+		return "", err
+	}
+
+	moves, err := a.analysisRepo.GetGameMoves(gameID)
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-ExportPGN-GetGameMoves: %w", err)
+		return "", err
+	}
+
+	game, err := replayMoves(gameRecord.StartingFen, moves)
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-ExportPGN-replayMoves: %w", err)
+		return "", err
+	}
+
+	white, black := a.pgnPlayers(gameRecord)
+
+	// Resignations and agreed draws leave no trace in the moves, so the
+	// stored result wins over the replayed one.
+	result := pgnResult(game)
+	if gameRecord.Result != models.ResultInProgress {
+		result = gameRecord.Result
+	}
+	tags := []helper.PGNTag{
+		{Key: "Event", Value: "VoiceMate game"},
+		{Key: "Site", Value: "VoiceMate"},
+		{Key: "Date", Value: pgnDate(gameRecord.CreatedAt)},
+		{Key: "Round", Value: "-"},
+		{Key: "White", Value: white},
+		{Key: "Black", Value: black},
+		{Key: "Result", Value: result},
+	}
+	if gameRecord.TimeControl != "" {
+		tags = append(tags, helper.PGNTag{Key: "TimeControl", Value: gameRecord.TimeControl})
+	}
+	if !helper.SameFEN(gameRecord.StartingFen, helper.StartingFEN) {
+		tags = append(tags,
+			helper.PGNTag{Key: "SetUp", Value: "1"},
+			helper.PGNTag{Key: "FEN", Value: gameRecord.StartingFen},
+		)
+	}
+
+	return helper.EncodePGN(tags, game, result), nil
+}
+
+// pgnPlayers names the White and Black players of a stored game, with the
+// bot on the side the player did not take.
+func (a *AnalysisService) pgnPlayers(record models.GameRecord) (white, black string) {
+	white, black = "Player", a.botName(record)
+	if record.PlayerColor == colorName(chess.Black) {
+		white, black = black, white
+	}
+	return white, black
+}
+
+// botName is the bot with the level and Elo it played at, e.g. "VoiceMate
+// Bot (medium, 1500)". Games stored without bot settings get the bare name.
+func (a *AnalysisService) botName(record models.GameRecord) string {
+	const name = "VoiceMate Bot"
+	if record.BotLevel == "" && record.BotElo == 0 {
+		return name
+	}
+
+	profile, ok := a.botLevels.Resolve(record.BotLevel, record.BotElo)
+	if !ok {
+		return fmt.Sprintf("%s (%s)", name, record.BotLevel)
+	}
+	return fmt.Sprintf("%s (%s, %d)", name, profile.Name, profile.Elo)
+}
+
+// pgnDate formats a stored timestamp as a PGN date (YYYY.MM.DD).
+func pgnDate(createdAt string) string {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02"} {
+		if date, err := time.Parse(layout, createdAt); err == nil {
+			return date.Format("2006.01.02")
+		}
+	}
+	return "????.??.??"
+}
+
+// GetGameReport evaluates every position of a stored game and scores each
+// move by centipawn loss, classification and accuracy, with per-side totals.
+func (a *AnalysisService) GetGameReport(gameID string) (models.GameReport, error) {
+	gameRecord, err := a.analysisRepo.GetGame(gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.GameReport{}, ErrGameNotFound
+	}
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-GetGameReport-GetGame: %w", err)
+		return models.GameReport{}, err
+	}
+
+	moves, err := a.analysisRepo.GetGameMoves(gameID)
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-GetGameReport-GetGameMoves: %w", err)
+		return models.GameReport{}, err
+	}
+
+	game, err := replayMoves(gameRecord.StartingFen, moves)
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-GetGameReport-replayMoves: %w", err)
+		return models.GameReport{}, err
+	}
+
+	takebacks, err := a.analysisRepo.CountTakebacks(gameID)
+	if err != nil {
+		err = fmt.Errorf("AnalysisService-GetGameReport-CountTakebacks: %w", err)
+		return models.GameReport{}, err
+	}
+
+	positions := game.Positions()
+	evaluations := make([]models.Evaluation, len(positions))
+
+	// Only positions missing from the cache need an engine; a fully cached
+	// game is reported without checking one out.
+	var pending []int
+	for i, position := range positions {
+		if evaluation, ok := terminalEvaluation(position); ok {
+			evaluations[i] = evaluation
+			continue
+		}
+		if evaluation, ok := a.cachedEvaluation(a.enginePool.EngineName(), position, models.ReportDepth); ok {
+			evaluations[i] = evaluation
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	if len(pending) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), gameReportTimeout)
+		defer cancel()
+
+		err = a.enginePool.Do(ctx, func(e *engine.Engine) error {
+			if err := e.NewGame(ctx, gameID); err != nil {
+				return err
+			}
+			for _, i := range pending {
+				evaluation, err := a.evaluatePosition(ctx, e, positions[i], models.ReportDepth)
+				if err != nil {
+					return err
+				}
+				evaluations[i] = evaluation
+			}
+			return nil
+		})
+		if err != nil {
+			err = fmt.Errorf("AnalysisService-GetGameReport-enginePool.Do: %w", err)
+			return models.GameReport{}, err
+		}
+	}
+
+	report := models.GameReport{
+		GameID:      gameID,
+		StartingFen: gameRecord.StartingFen,
+		Depth:       models.ReportDepth,
+		Takebacks:   takebacks,
+		Moves:       []models.MoveReport{},
+	}
+
+	for i, move := range game.Moves() {
+		mover := positions[i].Turn()
+		moveReport := models.MoveReport{
+			MoveOrder:  i + 1,
+			Move:       move.String(),
+			San:        chess.AlgebraicNotation{}.Encode(positions[i], move),
+			Color:      colorName(mover),
+			Fen:        positions[i+1].String(),
+			EvalBefore: evaluations[i],
+			EvalAfter:  evaluations[i+1],
+			BestMove:   evaluations[i].BestMove,
+		}
+		if i < len(moves) {
+			moveReport.MoveOrder = moves[i].MoveOrder
+		}
+
+		scoreMove(&moveReport, mover)
+		report.Moves = append(report.Moves, moveReport)
+	}
+
+	report.White = summarizeSide(report.Moves, colorName(chess.White))
+	report.Black = summarizeSide(report.Moves, colorName(chess.Black))
+
+	return report, nil
+}
+
+// evaluatePosition scores a position from white's point of view. Finished
+// positions are scored directly; everything else is searched to depth at full
+// strength and the result is written to the analyses cache.
+func (a *AnalysisService) evaluatePosition(ctx context.Context, e *engine.Engine, position *chess.Position, depth int) (models.Evaluation, error) {
+	if evaluation, ok := terminalEvaluation(position); ok {
+		return evaluation, nil
+	}
+
+	if err := e.SetOptions(ctx, difficulty.FullStrength); err != nil {
+		return models.Evaluation{}, fmt.Errorf("AnalysisService-evaluatePosition-SetOptions: %w", err)
+	}
+
+	result, err := e.Search(ctx, engine.SearchRequest{Fen: position.String(), Depth: depth})
+	if err != nil {
+		return models.Evaluation{}, fmt.Errorf("AnalysisService-evaluatePosition-Search: %w", err)
+	}
+
+	if len(result.Lines) == 0 {
+		return models.Evaluation{BestMove: result.BestMove}, nil
+	}
+
+	evaluation := whiteEvaluation(result.Lines[0], position.Turn())
+	evaluation.BestMove = result.BestMove
+
+	a.storeEvaluation(e.Name(), position, depth, evaluation)
+
+	return evaluation, nil
+}
+
+// cachedEvaluation looks up an analysis of the position at depth or deeper
+// made by engineName, the engine version the pool is currently running.
+func (a *AnalysisService) cachedEvaluation(engineName string, position *chess.Position, depth int) (models.Evaluation, bool) {
+	if engineName == "" {
+		return models.Evaluation{}, false
+	}
+
+	fen, err := helper.NormalizeFEN(position.String())
+	if err != nil {
+		return models.Evaluation{}, false
+	}
+
+	evaluation, err := a.analysisRepo.GetCachedAnalysis(fen, engineFamily(engineName), engineName, depth)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Println("AnalysisService-cachedEvaluation-GetCachedAnalysis", err)
+		}
+		return models.Evaluation{}, false
+	}
+
+	return evaluation, true
+}
+
+// storeEvaluation caches a search result. Failing to cache never fails the
+// request, so errors are only logged.
+func (a *AnalysisService) storeEvaluation(engineName string, position *chess.Position, depth int, evaluation models.Evaluation) {
+	if engineName == "" || evaluation.BestMove == "" {
+		return
+	}
+
+	fen, err := helper.NormalizeFEN(position.String())
+	if err != nil {
+		log.Println("AnalysisService-storeEvaluation-NormalizeFEN", err)
+		return
+	}
+
+	err = a.analysisRepo.SaveAnalysis(fen, engineFamily(engineName), engineName, depth, evaluation)
+	if err != nil {
+		log.Println("AnalysisService-storeEvaluation-SaveAnalysis", err)
+	}
+}
+
+// engineFamily is the engine name without its version, e.g. "stockfish" for
+// "Stockfish 16.1".
+func engineFamily(engineName string) string {
+	fields := strings.Fields(engineName)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
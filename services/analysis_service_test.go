@@ -0,0 +1,210 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/difficulty"
+	"samsungvoicebe/models"
+)
+
+func TestPGNPlayers(t *testing.T) {
+	service := NewAnalysisService(nil, nil, nil, difficulty.Default())
+
+	tests := []struct {
+		name      string
+		record    models.GameRecord
+		wantWhite string
+		wantBlack string
+	}{
+		{
+			name:      "player is white",
+			record:    models.GameRecord{PlayerColor: "white", BotLevel: "medium"},
+			wantWhite: "Player",
+			wantBlack: "VoiceMate Bot (medium, 1500)",
+		},
+		{
+			name:      "player is black",
+			record:    models.GameRecord{PlayerColor: "black", BotLevel: "hard"},
+			wantWhite: "VoiceMate Bot (hard, 2000)",
+			wantBlack: "Player",
+		},
+		{
+			name:      "target elo",
+			record:    models.GameRecord{PlayerColor: "white", BotElo: 1800},
+			wantWhite: "Player",
+			wantBlack: "VoiceMate Bot (custom, 1800)",
+		},
+		{
+			name:      "level no longer configured",
+			record:    models.GameRecord{PlayerColor: "white", BotLevel: "grandmaster"},
+			wantWhite: "Player",
+			wantBlack: "VoiceMate Bot (grandmaster)",
+		},
+		{
+			name:      "no bot settings",
+			record:    models.GameRecord{PlayerColor: "black"},
+			wantWhite: "VoiceMate Bot",
+			wantBlack: "Player",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			white, black := service.pgnPlayers(tt.record)
+			if white != tt.wantWhite || black != tt.wantBlack {
+				t.Errorf("pgnPlayers() = %q, %q, want %q, %q", white, black, tt.wantWhite, tt.wantBlack)
+			}
+		})
+	}
+}
+
+// fakeAnalysisRepo keeps the analyses cache in memory and looks it up the
+// way the SQL query does: same engine version, at least the asked depth,
+// deepest first.
+type fakeAnalysisRepo struct {
+	analyses []cachedAnalysis
+	getErr   error
+	saveErr  error
+	saves    int
+}
+
+type cachedAnalysis struct {
+	fen, engine, engineVersion string
+	depth                      int
+	evaluation                 models.Evaluation
+}
+
+func (f *fakeAnalysisRepo) GetCachedAnalysis(fen, engine, engineVersion string, depth int) (models.Evaluation, error) {
+	if f.getErr != nil {
+		return models.Evaluation{}, f.getErr
+	}
+	found := -1
+	for i, analysis := range f.analyses {
+		if analysis.fen != fen || analysis.engine != engine || analysis.engineVersion != engineVersion || analysis.depth < depth {
+			continue
+		}
+		if found == -1 || analysis.depth > f.analyses[found].depth {
+			found = i
+		}
+	}
+	if found == -1 {
+		return models.Evaluation{}, sql.ErrNoRows
+	}
+	return f.analyses[found].evaluation, nil
+}
+
+func (f *fakeAnalysisRepo) SaveAnalysis(fen, engine, engineVersion string, depth int, evaluation models.Evaluation) error {
+	f.saves++
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	f.analyses = append(f.analyses, cachedAnalysis{fen, engine, engineVersion, depth, evaluation})
+	return nil
+}
+
+func (f *fakeAnalysisRepo) GetGameHistoryList(string) ([]models.Game, error) { return nil, nil }
+func (f *fakeAnalysisRepo) GetGame(string) (models.GameRecord, error) {
+	return models.GameRecord{}, nil
+}
+func (f *fakeAnalysisRepo) GetGameMoves(string) ([]models.Move, error) { return nil, nil }
+func (f *fakeAnalysisRepo) GetMoveByOrder(int, string) (models.Move, error) {
+	return models.Move{}, nil
+}
+func (f *fakeAnalysisRepo) CountTakebacks(string) (int, error) { return 0, nil }
+
+func TestEvaluationCache(t *testing.T) {
+	const (
+		stockfish16 = "Stockfish 16"
+		stockfish17 = "Stockfish 17"
+		afterE4     = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+		// afterE4Later is afterE4 with other move counters.
+		afterE4Later = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 4 9"
+		afterD4      = "rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq - 0 1"
+	)
+	stored := models.Evaluation{CP: 30, BestMove: "e7e5", PV: []string{"e7e5", "g1f3"}}
+
+	tests := []struct {
+		name        string
+		storedBy    string
+		storedDepth int
+		engine      string
+		fen         string
+		depth       int
+		getErr      error
+		wantHit     bool
+	}{
+		{name: "same depth", storedBy: stockfish16, storedDepth: 18, engine: stockfish16, fen: afterE4, depth: 18, wantHit: true},
+		{name: "deeper analysis", storedBy: stockfish16, storedDepth: 22, engine: stockfish16, fen: afterE4, depth: 18, wantHit: true},
+		{name: "move counters are ignored", storedBy: stockfish16, storedDepth: 18, engine: stockfish16, fen: afterE4Later, depth: 18, wantHit: true},
+		{name: "shallower analysis", storedBy: stockfish16, storedDepth: 12, engine: stockfish16, fen: afterE4, depth: 18},
+		{name: "engine upgraded", storedBy: stockfish16, storedDepth: 18, engine: stockfish17, fen: afterE4, depth: 18},
+		{name: "engine not started", storedBy: stockfish16, storedDepth: 18, engine: "", fen: afterE4, depth: 18},
+		{name: "other position", storedBy: stockfish16, storedDepth: 18, engine: stockfish16, fen: afterD4, depth: 18},
+		{name: "lookup failure", storedBy: stockfish16, storedDepth: 18, engine: stockfish16, fen: afterE4, depth: 18, getErr: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeAnalysisRepo{}
+			service := NewAnalysisService(store, nil, nil, difficulty.Default())
+
+			service.storeEvaluation(tt.storedBy, testPosition(t, afterE4), tt.storedDepth, stored)
+			store.getErr = tt.getErr
+
+			got, ok := service.cachedEvaluation(tt.engine, testPosition(t, tt.fen), tt.depth)
+			if ok != tt.wantHit {
+				t.Fatalf("cachedEvaluation() hit = %v, want %v", ok, tt.wantHit)
+			}
+			if ok && !reflect.DeepEqual(got, stored) {
+				t.Errorf("cachedEvaluation() = %+v, want %+v", got, stored)
+			}
+		})
+	}
+}
+
+func TestStoreEvaluation(t *testing.T) {
+	const afterE4 = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+
+	tests := []struct {
+		name       string
+		engine     string
+		evaluation models.Evaluation
+		saveErr    error
+		wantSaves  int
+		wantCached bool
+	}{
+		{name: "stored", engine: "Stockfish 16", evaluation: models.Evaluation{BestMove: "e7e5"}, wantSaves: 1, wantCached: true},
+		{name: "write failure is only logged", engine: "Stockfish 16", evaluation: models.Evaluation{BestMove: "e7e5"}, saveErr: errors.New("disk full"), wantSaves: 1},
+		{name: "no engine name", evaluation: models.Evaluation{BestMove: "e7e5"}},
+		{name: "no best move", engine: "Stockfish 16"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeAnalysisRepo{saveErr: tt.saveErr}
+			service := NewAnalysisService(store, nil, nil, difficulty.Default())
+
+			service.storeEvaluation(tt.engine, testPosition(t, afterE4), 18, tt.evaluation)
+
+			if store.saves != tt.wantSaves {
+				t.Errorf("SaveAnalysis called %d times, want %d", store.saves, tt.wantSaves)
+			}
+			if cached := len(store.analyses) > 0; cached != tt.wantCached {
+				t.Errorf("cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func testPosition(t *testing.T, fen string) *chess.Position {
+	t.Helper()
+	fenOption, err := chess.FEN(fen)
+	if err != nil {
+		t.Fatalf("chess.FEN(%q): %v", fen, err)
+	}
+	return chess.NewGame(fenOption).Position()
+}
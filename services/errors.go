@@ -0,0 +1,27 @@
+package services
+
+import "errors"
+
+var (
+	ErrGameNotFound = errors.New("game not found")
+	ErrIllegalMove  = errors.New("illegal move")
+	ErrFenMismatch  = errors.New("fen does not match the game position")
+	ErrInvalidFen   = errors.New("invalid fen")
+	ErrGameOver     = errors.New("the game is already over")
+	ErrMoveConflict = errors.New("the game has changed, reload it and try again")
+
+	ErrNotPlayersTurn     = errors.New("it is not the player's turn")
+	ErrNoDrawOffer        = errors.New("no draw offer to accept")
+	ErrNothingToUndo      = errors.New("not enough moves to take back")
+	ErrInvalidUndo        = errors.New("invalid takeback")
+	ErrInvalidTimeControl = errors.New("invalid time control")
+
+	ErrUnknownBotLevel    = errors.New("unknown bot level")
+	ErrUnknownPersonality = errors.New("unknown bot personality")
+
+	ErrUnreplayableGame = errors.New("stored moves do not form a legal game")
+	ErrInvalidPGN       = errors.New("invalid pgn")
+
+	ErrVoiceTokenExpired = errors.New("voice token expired or unknown")
+	ErrInvalidBoardQuery = errors.New("invalid board query")
+)
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"time"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/helper"
+	"samsungvoicebe/models"
+)
+
+// gameClock works out the remaining time of both sides from the time
+// control and when each move was stored: a move's thinking time runs from
+// the previous move, or from the game's creation for the first one. While
+// the game is in progress the side to move's clock keeps running until now.
+// Untimed games and unreadable timestamps give no clock.
+func gameClock(record models.GameRecord, moves []models.Move, firstMover chess.Color, now time.Time) *models.GameClock {
+	if record.TimeControl == "" {
+		return nil
+	}
+	base, increment, err := helper.ParseTimeControl(record.TimeControl)
+	if err != nil {
+		return nil
+	}
+
+	last, err := parseTimestamp(record.CreatedAt)
+	if err != nil {
+		return nil
+	}
+
+	remaining := map[chess.Color]time.Duration{chess.White: base, chess.Black: base}
+	mover := firstMover
+	for _, move := range moves {
+		playedAt, err := parseTimestamp(move.CreatedAt)
+		if err != nil {
+			return nil
+		}
+		remaining[mover] += increment - playedAt.Sub(last)
+		last = playedAt
+		mover = mover.Other()
+	}
+
+	clock := &models.GameClock{}
+	if record.Result == models.ResultInProgress {
+		remaining[mover] -= now.Sub(last)
+		clock.Running = colorName(mover)
+	}
+
+	clock.WhiteMs = max(remaining[chess.White], 0).Milliseconds()
+	clock.BlackMs = max(remaining[chess.Black], 0).Milliseconds()
+	return clock
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	var parsed time.Time
+	var err error
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00"} {
+		if parsed, err = time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, err
+}
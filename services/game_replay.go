@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/helper"
+	"samsungvoicebe/models"
+)
+
+// replayMoves rebuilds a game by playing the stored moves, in move_order,
+// from startFen. Every stored move must be legal in the position reached so
+// far, otherwise ErrUnreplayableGame is returned.
+func replayMoves(startFen string, moves []models.Move) (*chess.Game, error) {
+	fenOption, err := chess.FEN(startFen)
+	if err != nil {
+		err = fmt.Errorf("replayMoves-chess.FEN: %w: %v", ErrInvalidFen, err)
+		return nil, err
+	}
+
+	game := chess.NewGame(fenOption)
+	for _, move := range moves {
+		legalMove, err := helper.DecodeMove(game.Position(), move.Move)
+		if err != nil {
+			err = fmt.Errorf("replayMoves-DecodeMove: %w: move %d (%s): %v", ErrUnreplayableGame, move.MoveOrder, move.Move, err)
+			return nil, err
+		}
+
+		if err = game.Move(legalMove); err != nil {
+			err = fmt.Errorf("replayMoves-game.Move: %w: move %d (%s): %v", ErrUnreplayableGame, move.MoveOrder, move.Move, err)
+			return nil, err
+		}
+	}
+
+	return game, nil
+}
+
+var terminations = map[chess.Method]string{
+	chess.Checkmate:            models.TerminationCheckmate,
+	chess.Stalemate:            models.TerminationStalemate,
+	chess.ThreefoldRepetition:  models.TerminationThreefoldRepetition,
+	chess.FivefoldRepetition:   models.TerminationFivefoldRepetition,
+	chess.FiftyMoveRule:        models.TerminationFiftyMoveRule,
+	chess.SeventyFiveMoveRule:  models.TerminationSeventyFiveMoveRule,
+	chess.InsufficientMaterial: models.TerminationInsufficientMaterial,
+	chess.Resignation:          models.TerminationResignation,
+	chess.DrawOffer:            models.TerminationDrawAgreement,
+}
+
+// gameOutcome reports how the game has ended, or nil while it goes on.
+// notnil/chess only makes threefold repetition and the fifty-move rule
+// claimable; they are claimed on game here, so games against the bot end on
+// them without anyone having to ask.
+func gameOutcome(game *chess.Game) *models.GameOutcome {
+	if game.Outcome() == chess.NoOutcome {
+		for _, method := range game.EligibleDraws() {
+			if method == chess.ThreefoldRepetition || method == chess.FiftyMoveRule {
+				if err := game.Draw(method); err == nil {
+					break
+				}
+			}
+		}
+	}
+	if game.Outcome() == chess.NoOutcome {
+		return nil
+	}
+	return newOutcome(game.Outcome().String(), terminations[game.Method()])
+}
+
+// newOutcome builds the outcome for a PGN result and termination.
+func newOutcome(result, termination string) *models.GameOutcome {
+	outcome := &models.GameOutcome{Result: result, Termination: termination}
+	switch result {
+	case models.ResultWhiteWins:
+		outcome.Winner = colorName(chess.White)
+	case models.ResultBlackWins:
+		outcome.Winner = colorName(chess.Black)
+	}
+	return outcome
+}
+
+// pgnResult is the PGN result token for the game's current outcome.
+func pgnResult(game *chess.Game) string {
+	if game.Outcome() == chess.NoOutcome {
+		return "*"
+	}
+	return game.Outcome().String()
+}
+
+// colorName is the lowercase color name used in API responses.
+func colorName(color chess.Color) string {
+	return strings.ToLower(color.Name())
+}
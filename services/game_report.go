@@ -0,0 +1,140 @@
+package services
+
+import (
+	"math"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/engine"
+	"samsungvoicebe/models"
+)
+
+// centipawnCap bounds evaluations when computing centipawn loss so a missed
+// mate doesn't dwarf every other mistake in the average.
+const centipawnCap = 1000
+
+// whiteEvaluation converts an engine line, scored for the side to move, into
+// an Evaluation from white's point of view.
+func whiteEvaluation(line engine.Line, turn chess.Color) models.Evaluation {
+	sign := 1
+	if turn == chess.Black {
+		sign = -1
+	}
+
+	evaluation := models.Evaluation{PV: line.PV}
+	if len(line.PV) > 0 {
+		evaluation.BestMove = line.PV[0]
+	}
+
+	if line.Score.IsMate {
+		mate := line.Score.Mate * sign
+		evaluation.Mate = &mate
+		evaluation.CP = models.MateScore * sign
+		if line.Score.Mate < 0 {
+			evaluation.CP = -models.MateScore * sign
+		}
+		return evaluation
+	}
+
+	evaluation.CP = line.Score.CP * sign
+	return evaluation
+}
+
+// terminalEvaluation scores a finished position without asking the engine.
+func terminalEvaluation(position *chess.Position) (models.Evaluation, bool) {
+	switch position.Status() {
+	case chess.Checkmate:
+		mate := 0
+		evaluation := models.Evaluation{CP: models.MateScore, Mate: &mate}
+		if position.Turn() == chess.White {
+			evaluation.CP = -models.MateScore
+		}
+		return evaluation, true
+	case chess.Stalemate:
+		return models.Evaluation{}, true
+	}
+	return models.Evaluation{}, false
+}
+
+func cappedCP(evaluation models.Evaluation) int {
+	return max(-centipawnCap, min(centipawnCap, evaluation.CP))
+}
+
+// winPercent maps a centipawn score to an expected score in percent, using
+// the same logistic curve as lichess.
+func winPercent(cp int) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(cp)))-1)
+}
+
+func moveAccuracy(winBefore, winAfter float64) float64 {
+	accuracy := 103.1668*math.Exp(-0.04354*(winBefore-winAfter)) - 3.1669
+	return math.Max(0, math.Min(100, accuracy))
+}
+
+func classifyMove(played, best string, centipawnLoss int) string {
+	switch {
+	case played == best:
+		return models.MoveClassBest
+	case centipawnLoss < 50:
+		return models.MoveClassGood
+	case centipawnLoss < 100:
+		return models.MoveClassInaccuracy
+	case centipawnLoss < 300:
+		return models.MoveClassMistake
+	default:
+		return models.MoveClassBlunder
+	}
+}
+
+// scoreMove fills in the loss, accuracy and classification of a move given
+// the white-POV evaluations before and after it.
+func scoreMove(report *models.MoveReport, mover chess.Color) {
+	sign := 1
+	if mover == chess.Black {
+		sign = -1
+	}
+
+	before := cappedCP(report.EvalBefore) * sign
+	after := cappedCP(report.EvalAfter) * sign
+
+	report.CentipawnLoss = max(0, before-after)
+	report.Accuracy = round1(moveAccuracy(winPercent(before), winPercent(after)))
+	report.Classification = classifyMove(report.Move, report.BestMove, report.CentipawnLoss)
+}
+
+func summarizeSide(moves []models.MoveReport, color string) models.SideSummary {
+	var summary models.SideSummary
+	var totalLoss, totalAccuracy float64
+	count := 0
+
+	for _, move := range moves {
+		if move.Color != color {
+			continue
+		}
+		count++
+		totalLoss += float64(move.CentipawnLoss)
+		totalAccuracy += move.Accuracy
+
+		switch move.Classification {
+		case models.MoveClassBest:
+			summary.Best++
+		case models.MoveClassGood:
+			summary.Good++
+		case models.MoveClassInaccuracy:
+			summary.Inaccuracies++
+		case models.MoveClassMistake:
+			summary.Mistakes++
+		case models.MoveClassBlunder:
+			summary.Blunders++
+		}
+	}
+
+	if count > 0 {
+		summary.AverageCentipawnLoss = round1(totalLoss / float64(count))
+		summary.Accuracy = round1(totalAccuracy / float64(count))
+	}
+	return summary
+}
+
+func round1(value float64) float64 {
+	return math.Round(value*10) / 10
+}
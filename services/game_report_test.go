@@ -0,0 +1,202 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/engine"
+	"samsungvoicebe/models"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestWhiteEvaluation(t *testing.T) {
+	tests := []struct {
+		name string
+		line engine.Line
+		turn chess.Color
+		want models.Evaluation
+	}{
+		{
+			name: "white to move",
+			line: engine.Line{Score: engine.Score{CP: 35}, PV: []string{"e2e4", "e7e5"}},
+			turn: chess.White,
+			want: models.Evaluation{CP: 35, BestMove: "e2e4", PV: []string{"e2e4", "e7e5"}},
+		},
+		{
+			name: "black to move",
+			line: engine.Line{Score: engine.Score{CP: 35}, PV: []string{"e7e5"}},
+			turn: chess.Black,
+			want: models.Evaluation{CP: -35, BestMove: "e7e5", PV: []string{"e7e5"}},
+		},
+		{
+			name: "white mates",
+			line: engine.Line{Score: engine.Score{Mate: 3, IsMate: true}},
+			turn: chess.White,
+			want: models.Evaluation{CP: models.MateScore, Mate: intPtr(3)},
+		},
+		{
+			name: "black mates",
+			line: engine.Line{Score: engine.Score{Mate: 2, IsMate: true}},
+			turn: chess.Black,
+			want: models.Evaluation{CP: -models.MateScore, Mate: intPtr(-2)},
+		},
+		{
+			name: "black is getting mated",
+			line: engine.Line{Score: engine.Score{Mate: -2, IsMate: true}},
+			turn: chess.Black,
+			want: models.Evaluation{CP: models.MateScore, Mate: intPtr(2)},
+		},
+		{
+			name: "white is getting mated",
+			line: engine.Line{Score: engine.Score{Mate: -1, IsMate: true}},
+			turn: chess.White,
+			want: models.Evaluation{CP: -models.MateScore, Mate: intPtr(-1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := whiteEvaluation(tt.line, tt.turn); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("whiteEvaluation() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminalEvaluation(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		want   models.Evaluation
+		wantOK bool
+	}{
+		{
+			name:   "white is mated",
+			fen:    "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3",
+			want:   models.Evaluation{CP: -models.MateScore, Mate: intPtr(0)},
+			wantOK: true,
+		},
+		{
+			name:   "black is mated",
+			fen:    "R5k1/5ppp/8/8/8/8/8/6K1 b - - 1 1",
+			want:   models.Evaluation{CP: models.MateScore, Mate: intPtr(0)},
+			wantOK: true,
+		},
+		{name: "stalemate", fen: "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", wantOK: true},
+		{name: "ongoing", fen: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fenOption, err := chess.FEN(tt.fen)
+			if err != nil {
+				t.Fatalf("chess.FEN: %v", err)
+			}
+
+			got, ok := terminalEvaluation(chess.NewGame(fenOption).Position())
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("terminalEvaluation() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClassifyMove(t *testing.T) {
+	tests := []struct {
+		played, best string
+		loss         int
+		want         string
+	}{
+		{"e2e4", "e2e4", 0, models.MoveClassBest},
+		{"e2e4", "e2e4", 500, models.MoveClassBest},
+		{"d2d4", "e2e4", 49, models.MoveClassGood},
+		{"d2d4", "e2e4", 50, models.MoveClassInaccuracy},
+		{"d2d4", "e2e4", 100, models.MoveClassMistake},
+		{"d2d4", "e2e4", 299, models.MoveClassMistake},
+		{"d2d4", "e2e4", 300, models.MoveClassBlunder},
+	}
+
+	for _, tt := range tests {
+		if got := classifyMove(tt.played, tt.best, tt.loss); got != tt.want {
+			t.Errorf("classifyMove(%s, %s, %d) = %s, want %s", tt.played, tt.best, tt.loss, got, tt.want)
+		}
+	}
+}
+
+func TestScoreMove(t *testing.T) {
+	tests := []struct {
+		name         string
+		before       int
+		after        int
+		mover        chess.Color
+		best         bool
+		wantLoss     int
+		wantAccuracy float64
+		wantClass    string
+	}{
+		{name: "best move keeps the evaluation", before: 30, after: 30, mover: chess.White, best: true, wantLoss: 0, wantAccuracy: 100, wantClass: models.MoveClassBest},
+		{name: "improving is no loss", before: 0, after: 80, mover: chess.White, wantLoss: 0, wantAccuracy: 100, wantClass: models.MoveClassGood},
+		{name: "white inaccuracy", before: 20, after: -40, mover: chess.White, wantLoss: 60, wantAccuracy: 78, wantClass: models.MoveClassInaccuracy},
+		{name: "black mistake", before: -50, after: 100, mover: chess.Black, wantLoss: 150, wantAccuracy: 53.7, wantClass: models.MoveClassMistake},
+		{name: "missed mate is capped", before: models.MateScore, after: 0, mover: chess.White, wantLoss: centipawnCap, wantAccuracy: 9.9, wantClass: models.MoveClassBlunder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := models.MoveReport{
+				Move:       "e2e4",
+				BestMove:   "d2d4",
+				EvalBefore: models.Evaluation{CP: tt.before},
+				EvalAfter:  models.Evaluation{CP: tt.after},
+			}
+			if tt.best {
+				report.BestMove = report.Move
+			}
+
+			scoreMove(&report, tt.mover)
+
+			if report.CentipawnLoss != tt.wantLoss || report.Accuracy != tt.wantAccuracy || report.Classification != tt.wantClass {
+				t.Errorf("scoreMove() = loss %d accuracy %v %s, want loss %d accuracy %v %s",
+					report.CentipawnLoss, report.Accuracy, report.Classification, tt.wantLoss, tt.wantAccuracy, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestSummarizeSide(t *testing.T) {
+	moves := []models.MoveReport{
+		{Color: "white", CentipawnLoss: 0, Accuracy: 100, Classification: models.MoveClassBest},
+		{Color: "black", CentipawnLoss: 150, Accuracy: 53.7, Classification: models.MoveClassMistake},
+		{Color: "white", CentipawnLoss: 60, Accuracy: 78, Classification: models.MoveClassInaccuracy},
+		{Color: "black", CentipawnLoss: 20, Accuracy: 90, Classification: models.MoveClassGood},
+		{Color: "white", CentipawnLoss: 1000, Accuracy: 9.9, Classification: models.MoveClassBlunder},
+	}
+
+	tests := []struct {
+		color string
+		moves []models.MoveReport
+		want  models.SideSummary
+	}{
+		{
+			color: "white",
+			moves: moves,
+			want:  models.SideSummary{Accuracy: 62.6, AverageCentipawnLoss: 353.3, Best: 1, Inaccuracies: 1, Blunders: 1},
+		},
+		{
+			color: "black",
+			moves: moves,
+			want:  models.SideSummary{Accuracy: 71.9, AverageCentipawnLoss: 85, Good: 1, Mistakes: 1},
+		},
+		{color: "white", moves: nil, want: models.SideSummary{}},
+	}
+
+	for _, tt := range tests {
+		if got := summarizeSide(tt.moves, tt.color); got != tt.want {
+			t.Errorf("summarizeSide(%d moves, %s) = %+v, want %+v", len(tt.moves), tt.color, got, tt.want)
+		}
+	}
+}
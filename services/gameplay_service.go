@@ -1,88 +1,852 @@
 package services
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/notnil/chess"
+	"samsungvoicebe/difficulty"
 	"samsungvoicebe/helper"
+	"samsungvoicebe/llm"
 	"samsungvoicebe/models"
+	"samsungvoicebe/narration"
+	"samsungvoicebe/pending"
 	"samsungvoicebe/repo"
+	"samsungvoicebe/tactics"
+	"samsungvoicebe/voice"
 )
 
+const (
+	// botDrawOfferPlies is how many plies must have been played before the
+	// bot offers a draw, and botDrawOfferMargin how level its evaluation
+	// must be, in centipawns.
+	botDrawOfferPlies  = 80
+	botDrawOfferMargin = 15
+
+	// botDrawAcceptMargin is the most the bot may be ahead, in centipawns,
+	// and still accept the player's draw offer.
+	botDrawAcceptMargin = 30
+)
+
+// GameplayStore keeps the games, their moves, hints and takebacks; in
+// production it is repo.GameplayRepo.
+type GameplayStore interface {
+	CreateGame(game models.GameRecord) (string, error)
+	GetGame(gameID string) (models.GameRecord, error)
+	DeleteGame(gameID string) error
+	FinishGame(gameID, result, termination string) error
+	GetUnfinishedGames(userID string) ([]models.UnfinishedGame, error)
+	AppendMoves(gameID string, ply int, moves []models.Move, outcome *models.GameOutcome) error
+	GetGameMoves(gameID string) ([]models.Move, error)
+	GetLastMove(gameID string) (models.Move, error)
+	GetRecentMoves(gameID string, limit int) ([]models.Move, error)
+	GetHintLevel(gameID, fen string) (int, error)
+	SaveHint(gameID, fen string, level int, bestMove string) error
+	SetDrawOffer(gameID, color string) error
+	TakeBack(takeback models.Takeback) error
+}
+
 type GameplayService struct {
-	gameplayRepo    *repo.GameplayRepo
+	gameplayRepo    GameplayStore
 	analysisService *AnalysisService
+	llm             llm.LLM
+	voiceChoices    *pending.Store[models.PendingChoice]
+	botLevels       *difficulty.Levels
 }
 
-func NewGameplayService(gameplayRepo *repo.GameplayRepo, analysisService *AnalysisService) *GameplayService {
+func NewGameplayService(gameplayRepo GameplayStore, analysisService *AnalysisService, llmClient llm.LLM, voiceChoices *pending.Store[models.PendingChoice], botLevels *difficulty.Levels) *GameplayService {
 	return &GameplayService{
 		gameplayRepo:    gameplayRepo,
 		analysisService: analysisService,
+		llm:             llmClient,
+		voiceChoices:    voiceChoices,
+		botLevels:       botLevels,
 	}
 }
 
-func (s *GameplayService) PlayerMove(gameID *string, fen, move, botLevel string) (models.BotMove, error) {
-	if gameID != nil {
-		err := s.gameplayRepo.GameMove(*gameID, fen, move)
+// BotLevels lists the levels players can pick, weakest first.
+func (s *GameplayService) BotLevels() []models.BotProfile {
+	return s.botLevels.List()
+}
+
+// BotPersonalities lists the playing styles players can pick.
+func (s *GameplayService) BotPersonalities() []models.Personality {
+	return models.Personalities
+}
+
+// PlayerMove plays the player's move and lets the bot answer with the given
+// settings. In a stored game only the player's side may move, settings left
+// out fall back to the ones the game was created with, and a move that ends
+// the game records its result.
+func (s *GameplayService) PlayerMove(gameID *string, fen, move string, bot models.BotSettings, narrationOptions models.NarrationOptions, includeWarnings bool) (models.BotMove, error) {
+	if gameID == nil {
+		profile, personality, err := s.botStyle(bot)
 		if err != nil {
-			err = fmt.Errorf("GameplayService-PlayerMove-GameMove: %w", err)
-			fmt.Printf("gameID: %s, fen: %s, move: %s", gameID, fen, move)
+			err = fmt.Errorf("GameplayService-PlayerMove-botStyle: %w", err)
 			return models.BotMove{}, err
 		}
+		fenOption, err := chess.FEN(fen)
+		if err != nil {
+			err = fmt.Errorf("GameplayService-PlayerMove-chess.FEN: %w: %v", ErrInvalidFen, err)
+			return models.BotMove{}, err
+		}
+		return s.botReply(nil, chess.NewGame(fenOption), 0, nil, profile, personality, narrationOptions, includeWarnings)
 	}
 
-	analysisResult, err := s.analysisService.StockfishAnalyze(fen, botLevel)
+	record, game, err := s.loadGame(*gameID)
 	if err != nil {
-		err = fmt.Errorf("GameplayService-PlayerMove-GameMove: %w", err)
+		err = fmt.Errorf("GameplayService-PlayerMove-loadGame: %w", err)
 		return models.BotMove{}, err
 	}
 
-	if gameID != nil {
-		err = s.gameplayRepo.GameMove(*gameID, analysisResult.Fen, analysisResult.BestMove)
-		if err != nil {
-			err = fmt.Errorf("GameplayService-PlayerMove-GameMove: %w", err)
-			fmt.Printf("gameID: %s, fen: %s, move: %s", gameID, fen, move)
+	if record.Result != models.ResultInProgress {
+		err = fmt.Errorf("GameplayService-PlayerMove: %w: %s by %s", ErrGameOver, record.Result, record.Termination)
+		return models.BotMove{}, err
+	}
+
+	profile, personality, err := s.botStyle(storedBotSettings(record, bot))
+	if err != nil {
+		err = fmt.Errorf("GameplayService-PlayerMove-botStyle: %w", err)
+		return models.BotMove{}, err
+	}
+
+	if turn := colorName(game.Position().Turn()); turn != record.PlayerColor {
+		err = fmt.Errorf("GameplayService-PlayerMove-Turn: %w: %s to move", ErrNotPlayersTurn, turn)
+		return models.BotMove{}, err
+	}
+
+	stored := len(game.Moves())
+	if _, err := playMove(game, move); err != nil {
+		err = fmt.Errorf("GameplayService-PlayerMove-playMove: %w", err)
+		return models.BotMove{}, err
+	}
+	playedAt := time.Now()
+
+	if playerFen := game.FEN(); fen != "" && !helper.SameFEN(fen, playerFen) {
+		err = fmt.Errorf("GameplayService-PlayerMove-SameFEN: %w: expected %s", ErrFenMismatch, playerFen)
+		return models.BotMove{}, err
+	}
+
+	// Storing the move withdraws any draw offer, so moving instead of
+	// accepting declines the bot's.
+	return s.botReply(gameID, game, stored, []time.Time{playedAt}, profile, personality, narrationOptions, includeWarnings)
+}
+
+// botReply lets Stockfish answer game's position at the profile's strength,
+// in the personality's style, and plays the reply on game. Stored games
+// persist every move of game after the first stored ones, played at the
+// times in playedAt, together with the reply, record the result when the
+// game ends and may get a draw offer from the bot. Finished games get no
+// reply, only their outcome. With includeWarnings the threats the player
+// faces after the reply are listed too.
+func (s *GameplayService) botReply(gameID *string, game *chess.Game, stored int, playedAt []time.Time, profile models.BotProfile, personality models.Personality, narrationOptions models.NarrationOptions, includeWarnings bool) (models.BotMove, error) {
+	fen := game.FEN()
+
+	if outcome := gameOutcome(game); outcome != nil {
+		if err := s.saveMoves(gameID, game, stored, playedAt, outcome); err != nil {
+			err = fmt.Errorf("GameplayService-botReply-saveMoves: %w", err)
 			return models.BotMove{}, err
 		}
+		return models.BotMove{Fen: fen, Outcome: outcome}, nil
+	}
+
+	var analysisGameID string
+	if gameID != nil {
+		analysisGameID = *gameID
+	}
+
+	analysisResult, err := s.analysisService.StockfishAnalyze(analysisGameID, fen, profile, personality)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-botReply-StockfishAnalyze: %w", err)
+		return models.BotMove{}, err
+	}
+
+	before := game.Position()
+	move, err := helper.DecodeMove(before, analysisResult.BestMove)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-botReply-DecodeMove: %w", err)
+		return models.BotMove{}, err
+	}
+	if err := game.Move(move); err != nil {
+		err = fmt.Errorf("GameplayService-botReply-game.Move: %w", err)
+		return models.BotMove{}, err
+	}
+	playedAt = append(playedAt, time.Now())
+
+	botMove := models.BotMove{
+		Fen:       game.FEN(),
+		Move:      move.String(),
+		Narration: narration.Move(before, move, narrationOptions),
+		Outcome:   gameOutcome(game),
+	}
+
+	if err := s.saveMoves(gameID, game, stored, playedAt, botMove.Outcome); err != nil {
+		err = fmt.Errorf("GameplayService-botReply-saveMoves: %w", err)
+		log.Printf("gameID: %s, fen: %s, move: %s", *gameID, game.FEN(), move)
+		return models.BotMove{}, err
+	}
+	if botMove.Outcome != nil {
+		return botMove, nil
 	}
-	
-	var botMove models.BotMove
 
-	botMove = models.BotMove{
-		Fen:  analysisResult.Fen,
-		Move: analysisResult.BestMove,
+	if includeWarnings {
+		botMove.Warnings = narration.Warnings(game.Position(), narrationOptions)
+	}
+
+	if gameID != nil && wantsDraw(game, analysisResult.Score) {
+		if err := s.gameplayRepo.SetDrawOffer(*gameID, colorName(before.Turn())); err != nil {
+			log.Println("GameplayService-botReply-SetDrawOffer", *gameID, err)
+		} else {
+			botMove.DrawOffered = true
+		}
 	}
 
 	return botMove, nil
 }
 
-func (s *GameplayService) CreateGame(userID string) (string, error) {
-	gameID, err := s.gameplayRepo.CreateGame(userID)
+// saveMoves stores the moves played on game after its first stored ones,
+// each with the time in playedAt it was played at, and its outcome when it
+// has ended. The times matter because the game clock is worked out from
+// them. It does nothing for games that are not stored. A game that moved on
+// meanwhile gives ErrMoveConflict.
+func (s *GameplayService) saveMoves(gameID *string, game *chess.Game, stored int, playedAt []time.Time, outcome *models.GameOutcome) error {
+	if gameID == nil {
+		return nil
+	}
+
+	positions := game.Positions()
+	var moves []models.Move
+	for i, move := range game.Moves()[stored:] {
+		storedMove := models.Move{Move: move.String(), Fen: positions[stored+i+1].String()}
+		if i < len(playedAt) {
+			storedMove.CreatedAt = playedAt[i].Format(time.RFC3339Nano)
+		}
+		moves = append(moves, storedMove)
+	}
+
+	err := s.gameplayRepo.AppendMoves(*gameID, stored, moves, outcome)
+	if errors.Is(err, repo.ErrMoveConflict) {
+		return fmt.Errorf("GameplayService-saveMoves-AppendMoves: %w: %v", ErrMoveConflict, err)
+	}
+	if err != nil {
+		return fmt.Errorf("GameplayService-saveMoves-AppendMoves: %w", err)
+	}
+	return nil
+}
+
+// wantsDraw decides whether the bot offers a draw after its move: only late
+// in the game and when its own evaluation is level.
+func wantsDraw(game *chess.Game, score *int) bool {
+	if score == nil || len(game.Moves()) < botDrawOfferPlies {
+		return false
+	}
+	return *score >= -botDrawOfferMargin && *score <= botDrawOfferMargin
+}
+
+// botStyle resolves the strength and personality the bot plays with.
+func (s *GameplayService) botStyle(bot models.BotSettings) (models.BotProfile, models.Personality, error) {
+	profile, ok := s.botLevels.Resolve(bot.BotLevel, bot.BotElo)
+	if !ok {
+		return models.BotProfile{}, models.Personality{}, fmt.Errorf("%w: %s", ErrUnknownBotLevel, bot.BotLevel)
+	}
+	personality, ok := models.GetPersonality(bot.Personality)
+	if !ok {
+		return models.BotProfile{}, models.Personality{}, fmt.Errorf("%w: %s", ErrUnknownPersonality, bot.Personality)
+	}
+	return profile, personality, nil
+}
+
+// currentFen returns the position the stored game is in, which is the FEN of
+// its latest move or its starting position when nothing has been played yet.
+func (s *GameplayService) currentFen(gameID string) (string, error) {
+	_, fen, err := s.currentGame(gameID)
+	return fen, err
+}
+
+// currentGame returns the stored game together with its current FEN.
+func (s *GameplayService) currentGame(gameID string) (models.GameRecord, string, error) {
+	game, err := s.gameplayRepo.GetGame(gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.GameRecord{}, "", ErrGameNotFound
+	}
+	if err != nil {
+		err = fmt.Errorf("GameplayService-currentGame-GetGame: %w", err)
+		return models.GameRecord{}, "", err
+	}
+
+	lastMove, err := s.gameplayRepo.GetLastMove(gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return game, game.StartingFen, nil
+	}
+	if err != nil {
+		err = fmt.Errorf("GameplayService-currentGame-GetLastMove: %w", err)
+		return models.GameRecord{}, "", err
+	}
+
+	return game, lastMove.Fen, nil
+}
+
+// fenTurn is the lowercase name of the side to move in fen.
+func fenTurn(fen string) string {
+	fenOption, err := chess.FEN(fen)
+	if err != nil {
+		return ""
+	}
+	return colorName(chess.NewGame(fenOption).Position().Turn())
+}
+
+// storedBotSettings fills the settings a move request left out with the ones
+// the game was created with.
+func storedBotSettings(game models.GameRecord, bot models.BotSettings) models.BotSettings {
+	if bot.BotLevel == "" && bot.BotElo == 0 {
+		bot.BotLevel = game.BotLevel
+		bot.BotElo = game.BotElo
+	}
+	if bot.Personality == "" {
+		bot.Personality = game.BotPersonality
+	}
+	return bot
+}
+
+// playMove plays a UCI or SAN move on game and returns it.
+func playMove(game *chess.Game, move string) (*chess.Move, error) {
+	if game.Outcome() != chess.NoOutcome {
+		return nil, fmt.Errorf("playMove: %w", ErrGameOver)
+	}
+
+	legalMove, err := helper.DecodeMove(game.Position(), move)
+	if err != nil {
+		return nil, fmt.Errorf("playMove-DecodeMove: %w: %v", ErrIllegalMove, err)
+	}
+
+	if err = game.Move(legalMove); err != nil {
+		return nil, fmt.Errorf("playMove-game.Move: %w: %v", ErrIllegalMove, err)
+	}
+
+	return legalMove, nil
+}
+
+// loadGame returns the stored game with its moves replayed from the starting
+// position.
+func (s *GameplayService) loadGame(gameID string) (models.GameRecord, *chess.Game, error) {
+	record, err := s.gameplayRepo.GetGame(gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.GameRecord{}, nil, ErrGameNotFound
+	}
+	if err != nil {
+		return models.GameRecord{}, nil, fmt.Errorf("GameplayService-loadGame-GetGame: %w", err)
+	}
+
+	moves, err := s.gameplayRepo.GetGameMoves(gameID)
+	if err != nil {
+		return models.GameRecord{}, nil, fmt.Errorf("GameplayService-loadGame-GetGameMoves: %w", err)
+	}
+
+	game, err := replayMoves(record.StartingFen, moves)
+	if err != nil {
+		return models.GameRecord{}, nil, fmt.Errorf("GameplayService-loadGame-replayMoves: %w", err)
+	}
+
+	return record, game, nil
+}
+
+// CreateGame stores a new game with the player's color, the bot's settings,
+// the starting position and the time control. When the bot has the first
+// move it is played straight away; if that fails the game is removed again.
+func (s *GameplayService) CreateGame(userID string, req models.CreateGameRequest) (models.CreatedGame, error) {
+	color := req.Color
+	if color == "" {
+		color = colorName(chess.White)
+	}
+
+	startingFen := helper.StartingFEN
+	if req.StartingFen != "" {
+		startingFen = req.StartingFen
+	}
+	fenOption, err := chess.FEN(startingFen)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-CreateGame-chess.FEN: %w: %v", ErrInvalidFen, err)
+		return models.CreatedGame{}, err
+	}
+	start := chess.NewGame(fenOption)
+	if start.Outcome() != chess.NoOutcome {
+		err = fmt.Errorf("GameplayService-CreateGame-Outcome: %w: the starting position is already over", ErrInvalidFen)
+		return models.CreatedGame{}, err
+	}
+	startingFen = start.FEN()
+
+	if req.TimeControl != "" {
+		if _, _, err := helper.ParseTimeControl(req.TimeControl); err != nil {
+			err = fmt.Errorf("GameplayService-CreateGame-ParseTimeControl: %w: %v", ErrInvalidTimeControl, err)
+			return models.CreatedGame{}, err
+		}
+	}
+
+	profile, personality, err := s.botStyle(req.BotSettings)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-CreateGame-botStyle: %w", err)
+		return models.CreatedGame{}, err
+	}
+
+	record := models.GameRecord{
+		UserID:         userID,
+		PlayerColor:    color,
+		BotElo:         req.BotElo,
+		BotPersonality: personality.Name,
+		StartingFen:    startingFen,
+		TimeControl:    req.TimeControl,
+	}
+	if req.BotElo == 0 {
+		record.BotLevel = profile.Name
+	}
+
+	gameID, err := s.gameplayRepo.CreateGame(record)
 	if err != nil {
 		err = fmt.Errorf("GameplayService-CreateGame-CreateGame: %w", err)
-		return "", err
+		return models.CreatedGame{}, err
+	}
+
+	created := models.CreatedGame{
+		GameID:      gameID,
+		Color:       color,
+		StartingFen: startingFen,
+		TimeControl: record.TimeControl,
+		BotLevel:    record.BotLevel,
+		BotElo:      record.BotElo,
+		Personality: record.BotPersonality,
+		Fen:         startingFen,
+	}
+
+	if colorName(start.Position().Turn()) != color {
+		botMove, err := s.botReply(&gameID, start, 0, nil, profile, personality, req.NarrationOptions, false)
+		if err != nil {
+			if deleteErr := s.gameplayRepo.DeleteGame(gameID); deleteErr != nil {
+				log.Println("GameplayService-CreateGame-DeleteGame", gameID, deleteErr)
+			}
+			err = fmt.Errorf("GameplayService-CreateGame-botReply: %w", err)
+			return models.CreatedGame{}, err
+		}
+		created.BotMove = &botMove
+		created.Fen = botMove.Fen
+	}
+
+	return created, nil
+}
+
+// Resign ends the game as a win for the bot.
+func (s *GameplayService) Resign(gameID string) (models.GameOutcome, error) {
+	record, err := s.activeGame(gameID)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-Resign-activeGame: %w", err)
+		return models.GameOutcome{}, err
+	}
+
+	result := models.ResultBlackWins
+	if record.PlayerColor == colorName(chess.Black) {
+		result = models.ResultWhiteWins
+	}
+	outcome := newOutcome(result, models.TerminationResignation)
+
+	if err := s.gameplayRepo.FinishGame(gameID, outcome.Result, outcome.Termination); err != nil {
+		err = fmt.Errorf("GameplayService-Resign-FinishGame: %w", err)
+		return models.GameOutcome{}, err
+	}
+
+	return *outcome, nil
+}
+
+// OfferDraw puts the player's draw offer to the bot. The bot accepts when it
+// has offered a draw itself or when a full-strength evaluation does not put
+// it clearly ahead.
+func (s *GameplayService) OfferDraw(gameID string) (models.DrawOfferResult, error) {
+	record, err := s.activeGame(gameID)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-OfferDraw-activeGame: %w", err)
+		return models.DrawOfferResult{}, err
+	}
+
+	botColor := colorName(chess.White)
+	if record.PlayerColor == botColor {
+		botColor = colorName(chess.Black)
+	}
+
+	if record.DrawOfferedBy != botColor {
+		fen, err := s.currentFen(gameID)
+		if err != nil {
+			err = fmt.Errorf("GameplayService-OfferDraw-currentFen: %w", err)
+			return models.DrawOfferResult{}, err
+		}
+
+		evaluation, err := s.analysisService.AnalyzePosition(gameID, fen, models.HintDepth)
+		if err != nil {
+			err = fmt.Errorf("GameplayService-OfferDraw-AnalyzePosition: %w", err)
+			return models.DrawOfferResult{}, err
+		}
+
+		botScore := evaluation.CP
+		if botColor == colorName(chess.Black) {
+			botScore = -botScore
+		}
+		if botScore > botDrawAcceptMargin {
+			return models.DrawOfferResult{Accepted: false}, nil
+		}
 	}
-	return gameID, nil
+
+	outcome := newOutcome(models.ResultDraw, models.TerminationDrawAgreement)
+	if err := s.gameplayRepo.FinishGame(gameID, outcome.Result, outcome.Termination); err != nil {
+		err = fmt.Errorf("GameplayService-OfferDraw-FinishGame: %w", err)
+		return models.DrawOfferResult{}, err
+	}
+
+	return models.DrawOfferResult{Accepted: true, Outcome: outcome}, nil
 }
 
-func (s *GameplayService) GetHint(fen string) (string, error) {
-	prompt := fmt.Sprintf(models.HintPrompt, fen)
-	hint := helper.PromptGemini(prompt)
+// AcceptDraw accepts the draw the bot offered with its last move.
+func (s *GameplayService) AcceptDraw(gameID string) (models.GameOutcome, error) {
+	record, err := s.activeGame(gameID)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-AcceptDraw-activeGame: %w", err)
+		return models.GameOutcome{}, err
+	}
+
+	if record.DrawOfferedBy == "" || record.DrawOfferedBy == record.PlayerColor {
+		err = fmt.Errorf("GameplayService-AcceptDraw: %w", ErrNoDrawOffer)
+		return models.GameOutcome{}, err
+	}
+
+	outcome := newOutcome(models.ResultDraw, models.TerminationDrawAgreement)
+	if err := s.gameplayRepo.FinishGame(gameID, outcome.Result, outcome.Termination); err != nil {
+		err = fmt.Errorf("GameplayService-AcceptDraw-FinishGame: %w", err)
+		return models.GameOutcome{}, err
+	}
+
+	return *outcome, nil
+}
+
+// Undo takes back the last plies half-moves of a game in progress and
+// records the takeback. Without plies it takes back the player's last move
+// and the bot's reply to it. The takeback must leave the player to move.
+func (s *GameplayService) Undo(gameID string, plies int) (models.UndoResult, error) {
+	record, err := s.activeGame(gameID)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-Undo-activeGame: %w", err)
+		return models.UndoResult{}, err
+	}
+
+	moves, err := s.gameplayRepo.GetGameMoves(gameID)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-Undo-GetGameMoves: %w", err)
+		return models.UndoResult{}, err
+	}
+
+	fenBefore := record.StartingFen
+	if len(moves) > 0 {
+		fenBefore = moves[len(moves)-1].Fen
+	}
+
+	if plies == 0 {
+		plies = 1
+		if fenTurn(fenBefore) == record.PlayerColor {
+			plies = 2
+		}
+	}
+	if plies > len(moves) {
+		err = fmt.Errorf("GameplayService-Undo: %w: %d plies asked, %d played", ErrNothingToUndo, plies, len(moves))
+		return models.UndoResult{}, err
+	}
+
+	kept := moves[:len(moves)-plies]
+	takeback := models.Takeback{
+		GameID:    gameID,
+		Plies:     plies,
+		FenBefore: fenBefore,
+		FenAfter:  record.StartingFen,
+		Moves:     []string{},
+	}
+	if len(kept) > 0 {
+		takeback.FromMoveOrder = kept[len(kept)-1].MoveOrder
+		takeback.FenAfter = kept[len(kept)-1].Fen
+	}
+	for _, move := range moves[len(kept):] {
+		takeback.Moves = append(takeback.Moves, move.Move)
+	}
+
+	turn := fenTurn(takeback.FenAfter)
+	if turn != record.PlayerColor {
+		err = fmt.Errorf("GameplayService-Undo: %w: taking back %d plies leaves %s to move", ErrInvalidUndo, plies, turn)
+		return models.UndoResult{}, err
+	}
+
+	err = s.gameplayRepo.TakeBack(takeback)
+	if errors.Is(err, repo.ErrMoveConflict) {
+		err = fmt.Errorf("GameplayService-Undo-TakeBack: %w: %v", ErrMoveConflict, err)
+		return models.UndoResult{}, err
+	}
+	if err != nil {
+		err = fmt.Errorf("GameplayService-Undo-TakeBack: %w", err)
+		return models.UndoResult{}, err
+	}
+
+	return models.UndoResult{
+		Fen:         takeback.FenAfter,
+		Turn:        turn,
+		UndoneMoves: takeback.Moves,
+	}, nil
+}
+
+// activeGame returns a stored game that has not ended yet.
+func (s *GameplayService) activeGame(gameID string) (models.GameRecord, error) {
+	record, err := s.gameplayRepo.GetGame(gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.GameRecord{}, ErrGameNotFound
+	}
+	if err != nil {
+		return models.GameRecord{}, fmt.Errorf("GameplayService-activeGame-GetGame: %w", err)
+	}
+
+	if record.Result != models.ResultInProgress {
+		return models.GameRecord{}, fmt.Errorf("%w: %s by %s", ErrGameOver, record.Result, record.Termination)
+	}
+
+	return record, nil
+}
+
+// GetGame returns a stored game with everything needed to carry on playing
+// it: its settings, the moves so far in UCI and SAN, the current position,
+// its status and, for timed games, both clocks.
+func (s *GameplayService) GetGame(gameID string) (models.GameState, error) {
+	record, err := s.gameplayRepo.GetGame(gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.GameState{}, ErrGameNotFound
+	}
+	if err != nil {
+		err = fmt.Errorf("GameplayService-GetGame-GetGame: %w", err)
+		return models.GameState{}, err
+	}
+
+	moves, err := s.gameplayRepo.GetGameMoves(gameID)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-GetGame-GetGameMoves: %w", err)
+		return models.GameState{}, err
+	}
+
+	game, err := replayMoves(record.StartingFen, moves)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-GetGame-replayMoves: %w", err)
+		return models.GameState{}, err
+	}
+
+	positions := game.Positions()
+	firstMover := positions[0].Turn()
+	position := game.Position()
+
+	state := models.GameState{
+		GameID:        record.ID,
+		CreatedAt:     record.CreatedAt,
+		Color:         record.PlayerColor,
+		BotLevel:      record.BotLevel,
+		BotElo:        record.BotElo,
+		Personality:   record.BotPersonality,
+		StartingFen:   record.StartingFen,
+		TimeControl:   record.TimeControl,
+		Fen:           position.String(),
+		Turn:          colorName(position.Turn()),
+		Status:        models.GameStatusInProgress,
+		InCheck:       tactics.InCheck(position),
+		Moves:         []models.GameMove{},
+		DrawOfferedBy: record.DrawOfferedBy,
+		Clock:         gameClock(record, moves, firstMover, time.Now()),
+	}
+
+	for i, move := range game.Moves() {
+		state.Moves = append(state.Moves, models.GameMove{
+			MoveOrder: moves[i].MoveOrder,
+			Move:      move.String(),
+			San:       chess.AlgebraicNotation{}.Encode(positions[i], move),
+			Color:     colorName(positions[i].Turn()),
+			Fen:       positions[i+1].String(),
+		})
+	}
+	if len(state.Moves) > 0 {
+		state.LastMove = &state.Moves[len(state.Moves)-1]
+	}
+
+	if record.Result != models.ResultInProgress {
+		state.Status = models.GameStatusFinished
+		state.Outcome = newOutcome(record.Result, record.Termination)
+	}
+
+	return state, nil
+}
+
+// GetUnfinishedGames lists the user's games that are still in progress, most
+// recently played first.
+func (s *GameplayService) GetUnfinishedGames(userID string) ([]models.UnfinishedGame, error) {
+	games, err := s.gameplayRepo.GetUnfinishedGames(userID)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-GetUnfinishedGames-GetUnfinishedGames: %w", err)
+		return []models.UnfinishedGame{}, err
+	}
+	if games == nil {
+		games = []models.UnfinishedGame{}
+	}
+	return games, nil
+}
+
+// GetHint grounds a tutor's hint in an engine analysis: the best move, its
+// line, the evaluation and the tactical themes are put in the prompt, and the
+// level decides how much of the move is given away. For stored games the
+// level rises with every hint asked for the same position and each hint is
+// recorded on the game.
+func (s *GameplayService) GetHint(req models.HintRequest) (models.Hint, error) {
+	position, err := s.requestedPosition(req.GameID, req.Fen)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-GetHint-requestedPosition: %w", err)
+		return models.Hint{}, err
+	}
+	if len(position.ValidMoves()) == 0 {
+		return models.Hint{}, fmt.Errorf("GameplayService-GetHint: %w", ErrGameOver)
+	}
+	fen := position.String()
+
+	level := req.Level
+	if level == 0 {
+		level = models.HintLevelIdea
+		if req.GameID != "" {
+			given, err := s.gameplayRepo.GetHintLevel(req.GameID, fen)
+			if err != nil {
+				err = fmt.Errorf("GameplayService-GetHint-GetHintLevel: %w", err)
+				return models.Hint{}, err
+			}
+			level = min(given+1, models.HintLevelMove)
+		}
+	}
+
+	evaluation, err := s.analysisService.AnalyzePosition(req.GameID, fen, models.HintDepth)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-GetHint-AnalyzePosition: %w", err)
+		return models.Hint{}, err
+	}
+
+	bestMove, err := helper.DecodeMove(position, evaluation.BestMove)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-GetHint-DecodeMove: %w", err)
+		return models.Hint{}, err
+	}
+	san := chess.AlgebraicNotation{}.Encode(position, bestMove)
+
+	prompt := fmt.Sprintf(models.HintPrompt,
+		fen,
+		colorName(position.Turn()),
+		describeEvaluation(evaluation, position.Turn()),
+		san,
+		sanLine(position, evaluation.PV),
+		orNone(strings.Join(tactics.Themes(position, bestMove), ", ")),
+		orNone(spokenWarnings(position)),
+		models.HintLevelInstructions[level],
+		languageName(req.Language),
+	)
+	text, err := s.llm.Generate(context.Background(), llm.TaskHint, prompt)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-GetHint-Generate: %w", err)
+		return models.Hint{}, err
+	}
+
+	hint := models.Hint{
+		Level: level,
+		Hint:  strings.TrimSpace(text),
+	}
+	if level >= models.HintLevelPiece {
+		hint.Square = bestMove.S1().String()
+	}
+	if level == models.HintLevelMove {
+		hint.Move = bestMove.String()
+		hint.San = san
+	}
+
+	if req.GameID != "" {
+		if err := s.gameplayRepo.SaveHint(req.GameID, fen, level, bestMove.String()); err != nil {
+			log.Println("GameplayService-GetHint-SaveHint", req.GameID, err)
+		}
+	}
 
 	return hint, nil
 }
 
-func (s *GameplayService) PlayerMoveByVoiceTranscription(fen, transcription string) (models.PlayerMoveByTranscription, error) {
-	prompt := fmt.Sprintf(models.MoveFromDescriptionPrompt, fen, transcription)
-	move := helper.PromptGemini(prompt)
-	move = strings.TrimSpace(move)
+// describeEvaluation puts an engine evaluation, which is from white's point
+// of view, into words for the side to move.
+func describeEvaluation(evaluation models.Evaluation, turn chess.Color) string {
+	sign := 1
+	if turn == chess.Black {
+		sign = -1
+	}
 
-	if move == models.InvalidMove {
-		err := fmt.Errorf("GameplayService-PlayerMoveByVoiceTranscription-PromptGemini: invalid move from transcription")
-		return models.PlayerMoveByTranscription{}, err
+	if evaluation.Mate != nil {
+		mate := *evaluation.Mate * sign
+		if mate > 0 {
+			return fmt.Sprintf("the side to move mates in %d", mate)
+		}
+		return fmt.Sprintf("the opponent mates in %d", -mate)
+	}
+
+	return fmt.Sprintf("%+.2f pawns", float64(evaluation.CP*sign)/100)
+}
+
+// sanLine writes the first moves of an engine line in SAN, stopping at the
+// first move that does not fit the position.
+func sanLine(position *chess.Position, pv []string) string {
+	const maxPlies = 6
+
+	var line []string
+	for _, uci := range pv {
+		if len(line) == maxPlies {
+			break
+		}
+		move, err := helper.DecodeMove(position, uci)
+		if err != nil {
+			break
+		}
+		line = append(line, chess.AlgebraicNotation{}.Encode(position, move))
+		position = position.Update(move)
+	}
+	return orNone(strings.Join(line, " "))
+}
+
+func spokenWarnings(position *chess.Position) string {
+	var spoken []string
+	for _, warning := range narration.Warnings(position, models.NarrationOptions{Language: voice.LanguageEnglish}) {
+		spoken = append(spoken, warning.Spoken)
+	}
+	return strings.Join(spoken, " ")
+}
+
+func languageName(language string) string {
+	if language == voice.LanguageIndonesian {
+		return "Indonesian"
 	}
+	return "English"
+}
 
+func orNone(text string) string {
+	if text == "" {
+		return "none"
+	}
+	return text
+}
+
+// PlayerMoveByVoiceTranscription answers what the player said. Questions
+// about the board and the game are answered from the position, hints come
+// from GetHint and undo, resign and draw offers are asked back for the client
+// to carry out. Anything else is a move, resolved with the rule-based voice
+// parser and only with the LLM when the parser cannot read it. When the
+// description fits several legal moves the player is asked which one they
+// meant instead.
+func (s *GameplayService) PlayerMoveByVoiceTranscription(gameID, fen, transcription string, narrationOptions models.NarrationOptions) (models.PlayerMoveByTranscription, error) {
 	position, err := chess.FEN(fen)
 	if err != nil {
 		err = fmt.Errorf("GameplayService-PlayerMoveByVoiceTranscription-chess.FEN: %w", err)
@@ -90,18 +854,397 @@ func (s *GameplayService) PlayerMoveByVoiceTranscription(fen, transcription stri
 	}
 
 	game := chess.NewGame(position)
-	err = game.MoveStr(move)
+
+	if narrationOptions.Language == "" {
+		narrationOptions.Language = voice.DetectLanguage(transcription)
+	}
+
+	command := voice.Classify(transcription)
+	if command.Intent != voice.IntentMove {
+		return s.answerVoiceCommand(gameID, game.Position(), command, narrationOptions)
+	}
+
+	var move *chess.Move
+	candidates := voice.ParseMove(game.Position(), transcription)
+	switch {
+	case len(candidates) == 1:
+		move = candidates[0]
+	case len(candidates) > 1:
+		return s.askToDisambiguate(game.Position(), candidates, narrationOptions), nil
+	default:
+		move, err = s.moveFromLLM(game.Position(), fen, transcription)
+		if err != nil {
+			err = fmt.Errorf("GameplayService-PlayerMoveByVoiceTranscription-moveFromLLM: %w", err)
+			return models.PlayerMoveByTranscription{}, err
+		}
+	}
+
+	return playVoiceMove(game.Position(), move, narrationOptions), nil
+}
+
+// answerVoiceCommand replies to an utterance that is not a move.
+func (s *GameplayService) answerVoiceCommand(gameID string, position *chess.Position, command voice.Command, narrationOptions models.NarrationOptions) (models.PlayerMoveByTranscription, error) {
+	reply := models.PlayerMoveByTranscription{
+		Status: models.VoiceStatusAnswered,
+		Intent: command.Intent,
+		Fen:    position.String(),
+	}
+
+	owner := position.Turn()
+	if command.Opponent {
+		owner = owner.Other()
+	}
+
+	switch command.Intent {
+	case voice.IntentSquareQuery:
+		reply.Answer = narration.Answer(position, narration.Query{Square: command.Square}, narrationOptions).Spoken
+
+	case voice.IntentPieceQuery:
+		query := narration.Query{Square: chess.NoSquare, Piece: command.Piece, Color: owner}
+		reply.Answer = narration.Answer(position, query, narrationOptions).Spoken
+
+	case voice.IntentAttackerQuery:
+		if command.Square != chess.NoSquare {
+			reply.Answer = narration.Attackers(position, command.Square, narrationOptions)
+		} else {
+			reply.Answer = narration.PieceAttackers(position, chess.NewPiece(command.Piece, owner), narrationOptions)
+		}
+
+	case voice.IntentLastMove, voice.IntentRepeat:
+		answer, err := s.lastMoveNarration(gameID, narrationOptions)
+		if err != nil {
+			err = fmt.Errorf("GameplayService-answerVoiceCommand-lastMoveNarration: %w", err)
+			return models.PlayerMoveByTranscription{}, err
+		}
+		reply.Answer = answer
+
+	case voice.IntentHint:
+		hint, err := s.GetHint(models.HintRequest{Fen: position.String(), GameID: gameID, NarrationOptions: narrationOptions})
+		if err != nil {
+			err = fmt.Errorf("GameplayService-answerVoiceCommand-GetHint: %w", err)
+			return models.PlayerMoveByTranscription{}, err
+		}
+		reply.Answer = hint.Hint
+
+	default:
+		reply.Status = models.VoiceStatusActionRequested
+		reply.Answer = narration.Prompt(command.Intent, narrationOptions)
+	}
+
+	return reply, nil
+}
+
+// lastMoveNarration reads out the latest move of a stored game. Repeat
+// requests get the same sentence, since the last thing said to the player
+// is the bot's reply.
+func (s *GameplayService) lastMoveNarration(gameID string, narrationOptions models.NarrationOptions) (string, error) {
+	if gameID == "" {
+		return narration.NoGame(narrationOptions), nil
+	}
+
+	game, err := s.gameplayRepo.GetGame(gameID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrGameNotFound
+		}
+		return "", fmt.Errorf("GameplayService-lastMoveNarration-GetGame: %w", err)
+	}
+
+	moves, err := s.gameplayRepo.GetRecentMoves(gameID, 2)
 	if err != nil {
-		err = fmt.Errorf("GameplayService-PlayerMoveByVoiceTranscription-game.Move: %w", err)
+		return "", fmt.Errorf("GameplayService-lastMoveNarration-GetRecentMoves: %w", err)
+	}
+	if len(moves) == 0 {
+		return narration.NoMoves(narrationOptions), nil
+	}
+
+	beforeFen := game.StartingFen
+	if len(moves) > 1 {
+		beforeFen = moves[1].Fen
+	}
+	fenOption, err := chess.FEN(beforeFen)
+	if err != nil {
+		return "", fmt.Errorf("GameplayService-lastMoveNarration-chess.FEN: %w: %v", ErrInvalidFen, err)
+	}
+
+	before := chess.NewGame(fenOption).Position()
+	move, err := helper.DecodeMove(before, moves[0].Move)
+	if err != nil {
+		return "", fmt.Errorf("GameplayService-lastMoveNarration-DecodeMove: %w: %v", ErrUnreplayableGame, err)
+	}
+
+	return narration.Move(before, move, narrationOptions), nil
+}
+
+// ResolveVoiceDisambiguation completes an ambiguous voice move with the
+// player's answer ("the one from b1", "yang dari b1"). An answer that still
+// fits several candidates gets a narrower question under a new token.
+func (s *GameplayService) ResolveVoiceDisambiguation(token, answer string) (models.PlayerMoveByTranscription, error) {
+	choice, ok := s.voiceChoices.Take(token)
+	if !ok {
+		return models.PlayerMoveByTranscription{}, ErrVoiceTokenExpired
+	}
+
+	position, matches, err := voice.ResolveChoice(choice, answer)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-ResolveVoiceDisambiguation-ResolveChoice: %w", err)
 		return models.PlayerMoveByTranscription{}, err
 	}
 
-	playerMoveFEN := game.FEN()
+	if len(matches) > 1 {
+		return s.askToDisambiguate(position, matches, choice.Narration), nil
+	}
 
-	playerMove := models.PlayerMoveByTranscription{
-		Move: move,
-		Fen:  playerMoveFEN,
+	return playVoiceMove(position, matches[0], choice.Narration), nil
+}
+
+func (s *GameplayService) askToDisambiguate(position *chess.Position, candidates []*chess.Move, narrationOptions models.NarrationOptions) models.PlayerMoveByTranscription {
+	token, expiresAt := s.voiceChoices.Put(voice.NewChoice(position, candidates, narrationOptions))
+	language := narrationOptions.Language
+
+	return models.PlayerMoveByTranscription{
+		Status: models.VoiceStatusDisambiguationRequired,
+		Intent: voice.IntentMove,
+		Fen:    position.String(),
+		Disambiguation: &models.Disambiguation{
+			Token:      token,
+			Question:   voice.Question(position, candidates, language),
+			Candidates: voice.Candidates(position, candidates, language),
+			ExpiresAt:  expiresAt,
+		},
 	}
+}
 
-	return playerMove, nil
+// playVoiceMove plays a move taken from position's legal moves.
+func playVoiceMove(position *chess.Position, move *chess.Move, narrationOptions models.NarrationOptions) models.PlayerMoveByTranscription {
+	return models.PlayerMoveByTranscription{
+		Status:    models.VoiceStatusMoved,
+		Intent:    voice.IntentMove,
+		Move:      chess.AlgebraicNotation{}.Encode(position, move),
+		Fen:       position.Update(move).String(),
+		Narration: narration.Move(position, move, narrationOptions),
+	}
+}
+
+// DescribeBoard lays out the position of a stored game, or of the given FEN,
+// for players who cannot see the board, answering the optional square, rank
+// or piece query along with it.
+func (s *GameplayService) DescribeBoard(req models.BoardDescriptionRequest) (models.BoardDescription, error) {
+	position, err := s.requestedPosition(req.GameID, req.Fen)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-DescribeBoard-requestedPosition: %w", err)
+		return models.BoardDescription{}, err
+	}
+
+	query, err := boardQuery(req)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-DescribeBoard-boardQuery: %w", err)
+		return models.BoardDescription{}, err
+	}
+
+	return narration.Board(position, query, req.NarrationOptions), nil
+}
+
+// GetWarnings lists what threatens the side to move: check, attacked pieces
+// that are not defended well enough, pins to the king and mate threats.
+func (s *GameplayService) GetWarnings(req models.WarningsRequest) (models.PositionWarnings, error) {
+	position, err := s.requestedPosition(req.GameID, req.Fen)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-GetWarnings-requestedPosition: %w", err)
+		return models.PositionWarnings{}, err
+	}
+
+	return models.PositionWarnings{
+		Fen:      position.String(),
+		Turn:     colorName(position.Turn()),
+		Warnings: narration.Warnings(position, req.NarrationOptions),
+	}, nil
+}
+
+// requestedPosition is the current position of the stored game when gameID
+// is set, otherwise the given FEN.
+func (s *GameplayService) requestedPosition(gameID, fen string) (*chess.Position, error) {
+	if gameID != "" {
+		var err error
+		fen, err = s.currentFen(gameID)
+		if err != nil {
+			err = fmt.Errorf("GameplayService-requestedPosition-currentFen: %w", err)
+			return nil, err
+		}
+	}
+
+	fenOption, err := chess.FEN(fen)
+	if err != nil {
+		err = fmt.Errorf("GameplayService-requestedPosition-chess.FEN: %w: %v", ErrInvalidFen, err)
+		return nil, err
+	}
+
+	return chess.NewGame(fenOption).Position(), nil
+}
+
+func boardQuery(req models.BoardDescriptionRequest) (narration.Query, error) {
+	query := narration.Query{Square: chess.NoSquare, Rank: req.Rank}
+
+	if req.Square != "" {
+		square, ok := voice.ParseSquare(req.Square)
+		if !ok {
+			return narration.Query{}, fmt.Errorf("%w: unknown square %q", ErrInvalidBoardQuery, req.Square)
+		}
+		query.Square = square
+	}
+
+	if req.Piece != "" {
+		piece, ok := voice.ParsePiece(req.Piece)
+		if !ok {
+			return narration.Query{}, fmt.Errorf("%w: unknown piece %q", ErrInvalidBoardQuery, req.Piece)
+		}
+		query.Piece = piece
+	}
+
+	switch req.Color {
+	case "white":
+		query.Color = chess.White
+	case "black":
+		query.Color = chess.Black
+	}
+
+	return query, nil
+}
+
+func (s *GameplayService) moveFromLLM(position *chess.Position, fen, transcription string) (*chess.Move, error) {
+	prompt := fmt.Sprintf(models.MoveFromDescriptionPrompt, fen, transcription)
+	move, err := s.llm.Generate(context.Background(), llm.TaskMoveParsing, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("GameplayService-moveFromLLM-Generate: %w", err)
+	}
+	move = strings.TrimSpace(move)
+
+	if move == models.InvalidMove {
+		return nil, fmt.Errorf("GameplayService-moveFromLLM-Generate: invalid move from transcription")
+	}
+
+	legalMove, err := helper.DecodeMove(position, move)
+	if err != nil {
+		return nil, fmt.Errorf("GameplayService-moveFromLLM-DecodeMove: %w", err)
+	}
+
+	return legalMove, nil
+}
+
+// ImportPGN creates one game per PGN game in the file and stores every move
+// with the FEN it leads to. Games that fail to parse or store are reported in
+// the result's errors instead of aborting the whole import.
+func (s *GameplayService) ImportPGN(userID, pgn string) (models.PGNImportResult, error) {
+	result := models.PGNImportResult{
+		Imported: []models.ImportedGame{},
+		Errors:   []models.PGNImportError{},
+	}
+
+	gameTexts := helper.SplitPGN(pgn)
+	if len(gameTexts) == 0 {
+		err := fmt.Errorf("GameplayService-ImportPGN-SplitPGN: %w", ErrInvalidPGN)
+		return models.PGNImportResult{}, err
+	}
+
+	for i, gameText := range gameTexts {
+		index := i + 1
+
+		importedGame, err := s.importGame(userID, gameText)
+		if err != nil {
+			log.Println("GameplayService-ImportPGN-importGame", index, err)
+			result.Errors = append(result.Errors, models.PGNImportError{Index: index, Error: err.Error()})
+			continue
+		}
+
+		importedGame.Index = index
+		result.Imported = append(result.Imported, importedGame)
+	}
+
+	return result, nil
+}
+
+func (s *GameplayService) importGame(userID, gameText string) (models.ImportedGame, error) {
+	pgnOption, err := chess.PGN(strings.NewReader(gameText))
+	if err != nil {
+		return models.ImportedGame{}, fmt.Errorf("%w: %v", ErrInvalidPGN, err)
+	}
+
+	game := chess.NewGame(pgnOption)
+	moves := game.Moves()
+	if len(moves) == 0 {
+		return models.ImportedGame{}, fmt.Errorf("%w: game has no moves", ErrInvalidPGN)
+	}
+
+	positions := game.Positions()
+	record := models.GameRecord{
+		UserID:      userID,
+		PlayerColor: colorName(chess.White),
+		StartingFen: positions[0].String(),
+	}
+	if tag := game.GetTagPair("TimeControl"); tag != nil {
+		if _, _, err := helper.ParseTimeControl(tag.Value); err == nil {
+			record.TimeControl = tag.Value
+		}
+	}
+
+	gameID, err := s.gameplayRepo.CreateGame(record)
+	if err != nil {
+		return models.ImportedGame{}, fmt.Errorf("GameplayService-importGame-CreateGame: %w", err)
+	}
+
+	storedMoves := make([]models.Move, 0, len(moves))
+	for i, move := range moves {
+		storedMoves = append(storedMoves, models.Move{Move: move.String(), Fen: positions[i+1].String()})
+	}
+	outcome := importedOutcome(game)
+	if err := s.gameplayRepo.AppendMoves(gameID, 0, storedMoves, outcome); err != nil {
+		if deleteErr := s.gameplayRepo.DeleteGame(gameID); deleteErr != nil {
+			log.Println("GameplayService-importGame-DeleteGame", gameID, deleteErr)
+		}
+		return models.ImportedGame{}, fmt.Errorf("GameplayService-importGame-AppendMoves: %w", err)
+	}
+
+	importedGame := models.ImportedGame{
+		GameID:    gameID,
+		MoveCount: len(moves),
+		Result:    models.ResultInProgress,
+	}
+	if outcome != nil {
+		importedGame.Result = outcome.Result
+	}
+	if tag := game.GetTagPair("White"); tag != nil {
+		importedGame.White = tag.Value
+	}
+	if tag := game.GetTagPair("Black"); tag != nil {
+		importedGame.Black = tag.Value
+	}
+
+	return importedGame, nil
+}
+
+// importedOutcome is how an imported game ended: from its final position
+// when that decides it, otherwise from the result in the PGN's move text or
+// Result tag, which covers resignations and agreed draws. Games the PGN
+// leaves open have none. A game without a result token in its move text has
+// an empty outcome rather than "*".
+func importedOutcome(game *chess.Game) *models.GameOutcome {
+	if outcome := gameOutcome(game); outcome != nil && decidedResult(outcome.Result) {
+		if outcome.Termination == "" {
+			outcome.Termination = models.TerminationImported
+		}
+		return outcome
+	}
+
+	if tag := game.GetTagPair("Result"); tag != nil && decidedResult(tag.Value) {
+		return newOutcome(tag.Value, models.TerminationImported)
+	}
+	return nil
+}
+
+func decidedResult(result string) bool {
+	switch result {
+	case models.ResultWhiteWins, models.ResultBlackWins, models.ResultDraw:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/difficulty"
+	"samsungvoicebe/helper"
+	"samsungvoicebe/llm"
+	"samsungvoicebe/models"
+	"samsungvoicebe/pending"
+	"samsungvoicebe/repo"
+)
+
+func TestImportedOutcome(t *testing.T) {
+	tests := []struct {
+		name string
+		pgn  string
+		want *models.GameOutcome
+	}{
+		{
+			name: "checkmate",
+			pgn:  "1. f3 e5 2. g4 Qh4# 0-1",
+			want: &models.GameOutcome{Result: models.ResultBlackWins, Termination: models.TerminationCheckmate, Winner: "black"},
+		},
+		{
+			name: "resignation in the move text",
+			pgn:  "1. e4 e5 2. Qh5 Nc6 1-0",
+			want: &models.GameOutcome{Result: models.ResultWhiteWins, Termination: models.TerminationImported, Winner: "white"},
+		},
+		{
+			name: "result tag only",
+			pgn:  "[Result \"1/2-1/2\"]\n\n1. e4 e5",
+			want: &models.GameOutcome{Result: models.ResultDraw, Termination: models.TerminationImported},
+		},
+		{
+			name: "unfinished",
+			pgn:  "[Result \"*\"]\n\n1. e4 e5 *",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pgnOption, err := chess.PGN(strings.NewReader(tt.pgn))
+			if err != nil {
+				t.Fatalf("chess.PGN: %v", err)
+			}
+
+			got := importedOutcome(chess.NewGame(pgnOption))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("importedOutcome() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newVoiceTestService(fake *llm.Fake) *GameplayService {
+	return NewGameplayService(nil, nil, fake, pending.NewStore[models.PendingChoice](time.Minute), nil)
+}
+
+func TestPlayerMoveByVoiceTranscription(t *testing.T) {
+	tests := []struct {
+		name          string
+		fen           string
+		transcription string
+		llmResponse   string
+		llmErr        error
+		wantStatus    string
+		wantMove      string
+		wantLLMCalls  int
+		wantErr       bool
+	}{
+		{
+			name:          "parser reads the move",
+			fen:           helper.StartingFEN,
+			transcription: "knight to f3",
+			wantStatus:    models.VoiceStatusMoved,
+			wantMove:      "Nf3",
+		},
+		{
+			name:          "llm reads the move",
+			fen:           helper.StartingFEN,
+			transcription: "do the usual opening thing",
+			llmResponse:   "e2e4\n",
+			wantStatus:    models.VoiceStatusMoved,
+			wantMove:      "e4",
+			wantLLMCalls:  1,
+		},
+		{
+			name:          "llm cannot read the move",
+			fen:           helper.StartingFEN,
+			transcription: "do the usual opening thing",
+			llmResponse:   models.InvalidMove,
+			wantLLMCalls:  1,
+			wantErr:       true,
+		},
+		{
+			name:          "llm answers an illegal move",
+			fen:           helper.StartingFEN,
+			transcription: "do the usual opening thing",
+			llmResponse:   "e2e5",
+			wantLLMCalls:  1,
+			wantErr:       true,
+		},
+		{
+			name:          "llm failure",
+			fen:           helper.StartingFEN,
+			transcription: "do the usual opening thing",
+			llmErr:        errors.New("quota exceeded"),
+			wantLLMCalls:  1,
+			wantErr:       true,
+		},
+		{
+			name:          "ambiguous move",
+			fen:           "4k3/8/8/8/8/8/4K3/R6R w - - 0 1",
+			transcription: "rook d1",
+			wantStatus:    models.VoiceStatusDisambiguationRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := llm.NewFake()
+			fake.Queue(llm.TaskMoveParsing, tt.llmResponse)
+			fake.Err = tt.llmErr
+			service := newVoiceTestService(fake)
+
+			got, err := service.PlayerMoveByVoiceTranscription("", tt.fen, tt.transcription, models.NarrationOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PlayerMoveByVoiceTranscription(%q) error = %v, wantErr %v", tt.transcription, err, tt.wantErr)
+			}
+			if len(fake.Calls) != tt.wantLLMCalls {
+				t.Errorf("LLM called %d times, want %d", len(fake.Calls), tt.wantLLMCalls)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Status != tt.wantStatus || got.Move != tt.wantMove {
+				t.Errorf("PlayerMoveByVoiceTranscription(%q) = %s %q, want %s %q", tt.transcription, got.Status, got.Move, tt.wantStatus, tt.wantMove)
+			}
+		})
+	}
+}
+
+func TestResolveVoiceDisambiguation(t *testing.T) {
+	service := newVoiceTestService(llm.NewFake())
+
+	asked, err := service.PlayerMoveByVoiceTranscription("", "4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "rook d1", models.NarrationOptions{})
+	if err != nil || asked.Disambiguation == nil {
+		t.Fatalf("PlayerMoveByVoiceTranscription did not ask which rook: %+v, %v", asked, err)
+	}
+
+	got, err := service.ResolveVoiceDisambiguation(asked.Disambiguation.Token, "yang dari h1")
+	if err != nil {
+		t.Fatalf("ResolveVoiceDisambiguation: %v", err)
+	}
+	if got.Status != models.VoiceStatusMoved || got.Move != "Rhd1" {
+		t.Errorf("ResolveVoiceDisambiguation = %s %q, want %s %q", got.Status, got.Move, models.VoiceStatusMoved, "Rhd1")
+	}
+
+	if _, err := service.ResolveVoiceDisambiguation(asked.Disambiguation.Token, "yang dari h1"); !errors.Is(err, ErrVoiceTokenExpired) {
+		t.Errorf("reusing the token = %v, want ErrVoiceTokenExpired", err)
+	}
+}
+
+// fakeGameplayRepo keeps one game in memory. racing is how many moves
+// another request stores between a move being read and appended, and
+// appendErr fails AppendMoves the way the database would.
+type fakeGameplayRepo struct {
+	game      models.GameRecord
+	moves     []models.Move
+	racing    int
+	appendErr error
+	takeback  *models.Takeback
+}
+
+func (f *fakeGameplayRepo) GetGame(gameID string) (models.GameRecord, error) {
+	if gameID != f.game.ID {
+		return models.GameRecord{}, sql.ErrNoRows
+	}
+	return f.game, nil
+}
+
+func (f *fakeGameplayRepo) GetGameMoves(string) ([]models.Move, error) {
+	return f.moves, nil
+}
+
+func (f *fakeGameplayRepo) AppendMoves(gameID string, ply int, moves []models.Move, outcome *models.GameOutcome) error {
+	if f.appendErr != nil {
+		return f.appendErr
+	}
+	if stored := len(f.moves) + f.racing; stored != ply {
+		return fmt.Errorf("%w: %d moves stored, expected %d", repo.ErrMoveConflict, stored, ply)
+	}
+	for i, move := range moves {
+		move.MoveOrder = ply + i + 1
+		f.moves = append(f.moves, move)
+	}
+	if outcome != nil {
+		f.game.Result, f.game.Termination = outcome.Result, outcome.Termination
+	}
+	return nil
+}
+
+func (f *fakeGameplayRepo) TakeBack(takeback models.Takeback) error {
+	f.takeback = &takeback
+	return nil
+}
+
+func (f *fakeGameplayRepo) CreateGame(models.GameRecord) (string, error)      { return "", nil }
+func (f *fakeGameplayRepo) DeleteGame(string) error                           { return nil }
+func (f *fakeGameplayRepo) FinishGame(string, string, string) error           { return nil }
+func (f *fakeGameplayRepo) GetLastMove(string) (models.Move, error)           { return models.Move{}, nil }
+func (f *fakeGameplayRepo) GetRecentMoves(string, int) ([]models.Move, error) { return nil, nil }
+func (f *fakeGameplayRepo) GetHintLevel(string, string) (int, error)          { return 0, nil }
+func (f *fakeGameplayRepo) SaveHint(string, string, int, string) error        { return nil }
+func (f *fakeGameplayRepo) SetDrawOffer(string, string) error                 { return nil }
+func (f *fakeGameplayRepo) GetUnfinishedGames(string) ([]models.UnfinishedGame, error) {
+	return nil, nil
+}
+
+// storedGame returns a game in progress from the starting position in which
+// the player has color and the given moves have been played.
+func storedGame(t *testing.T, color string, moves ...string) *fakeGameplayRepo {
+	t.Helper()
+	game := chess.NewGame()
+	f := &fakeGameplayRepo{game: models.GameRecord{
+		ID:          "game-1",
+		PlayerColor: color,
+		BotLevel:    "medium",
+		StartingFen: helper.StartingFEN,
+		Result:      models.ResultInProgress,
+	}}
+	for i, move := range moves {
+		if _, err := playMove(game, move); err != nil {
+			t.Fatalf("playing %s: %v", move, err)
+		}
+		f.moves = append(f.moves, models.Move{Move: move, Fen: game.FEN(), MoveOrder: i + 1})
+	}
+	return f
+}
+
+func TestPlayerMove(t *testing.T) {
+	// After 1. f3 e5 2. g4 black mates with Qh4, so the bot never answers.
+	foolsMate := []string{"f2f3", "e7e5", "g2g4"}
+	const mateFen = "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3"
+
+	tests := []struct {
+		name      string
+		gameID    string
+		color     string
+		fen       string
+		move      string
+		racing    int
+		wantErr   error
+		wantMoves int
+	}{
+		{name: "mating move is stored", color: "black", fen: mateFen, move: "d8h4", wantMoves: 4},
+		{name: "move without a fen", color: "black", move: "d8h4", wantMoves: 4},
+		{name: "unknown game", gameID: "game-2", color: "black", move: "d8h4", wantErr: ErrGameNotFound, wantMoves: 3},
+		{name: "bot's turn", color: "white", move: "d8h4", wantErr: ErrNotPlayersTurn, wantMoves: 3},
+		{name: "illegal move", color: "black", move: "d8h5", wantErr: ErrIllegalMove, wantMoves: 3},
+		{name: "fen mismatch", color: "black", fen: helper.StartingFEN, move: "d8h4", wantErr: ErrFenMismatch, wantMoves: 3},
+		{name: "stale ply", color: "black", fen: mateFen, move: "d8h4", racing: 1, wantErr: ErrMoveConflict, wantMoves: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storedGame(t, tt.color, foolsMate...)
+			store.racing = tt.racing
+			service := NewGameplayService(store, nil, nil, nil, difficulty.Default())
+
+			gameID := store.game.ID
+			if tt.gameID != "" {
+				gameID = tt.gameID
+			}
+
+			got, err := service.PlayerMove(&gameID, tt.fen, tt.move, models.BotSettings{}, models.NarrationOptions{}, false)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlayerMove(%s) error = %v, want %v", tt.move, err, tt.wantErr)
+			}
+			if len(store.moves) != tt.wantMoves {
+				t.Errorf("%d moves stored, want %d", len(store.moves), tt.wantMoves)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got.Outcome == nil || got.Outcome.Result != models.ResultBlackWins {
+				t.Errorf("PlayerMove() outcome = %+v, want black wins", got.Outcome)
+			}
+			if store.game.Result != models.ResultBlackWins {
+				t.Errorf("stored result = %q, want %q", store.game.Result, models.ResultBlackWins)
+			}
+		})
+	}
+}
+
+func TestSaveMovesConflict(t *testing.T) {
+	dbErr := errors.New("connection reset")
+
+	tests := []struct {
+		name         string
+		racing       int
+		appendErr    error
+		wantConflict bool
+		wantErr      error
+	}{
+		{name: "stored"},
+		{name: "expected ply is stale", racing: 1, wantConflict: true},
+		{name: "move already stored", appendErr: fmt.Errorf("%w: move 2 is already stored", repo.ErrMoveConflict), wantConflict: true},
+		{name: "game no longer in progress", appendErr: fmt.Errorf("%w: game is no longer in progress", repo.ErrMoveConflict), wantConflict: true},
+		{name: "database error", appendErr: dbErr, wantErr: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storedGame(t, "white", "e2e4")
+			store.racing = tt.racing
+			store.appendErr = tt.appendErr
+			service := NewGameplayService(store, nil, nil, nil, nil)
+
+			game, err := replayMoves(store.game.StartingFen, store.moves)
+			if err != nil {
+				t.Fatalf("replayMoves: %v", err)
+			}
+			if _, err := playMove(game, "e7e5"); err != nil {
+				t.Fatalf("playMove: %v", err)
+			}
+
+			err = service.saveMoves(&store.game.ID, game, 1, nil, nil)
+			if errors.Is(err, ErrMoveConflict) != tt.wantConflict {
+				t.Fatalf("saveMoves() error = %v, want conflict %v", err, tt.wantConflict)
+			}
+			if tt.wantConflict {
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("saveMoves() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUndo(t *testing.T) {
+	afterE4 := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+	afterE5 := "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"
+
+	tests := []struct {
+		name        string
+		color       string
+		moves       []string
+		plies       int
+		finished    bool
+		wantErr     error
+		wantFen     string
+		wantUndone  []string
+		wantFromPly int
+	}{
+		{
+			name:       "player's move and the bot's reply",
+			color:      "white",
+			moves:      []string{"e2e4", "e7e5"},
+			wantFen:    helper.StartingFEN,
+			wantUndone: []string{"e2e4", "e7e5"},
+		},
+		{
+			name:       "player's move the bot has not answered",
+			color:      "white",
+			moves:      []string{"e2e4"},
+			wantFen:    helper.StartingFEN,
+			wantUndone: []string{"e2e4"},
+		},
+		{
+			name:        "keeps the earlier moves",
+			color:       "black",
+			moves:       []string{"e2e4", "e7e5", "g1f3"},
+			wantFen:     afterE4,
+			wantUndone:  []string{"e7e5", "g1f3"},
+			wantFromPly: 1,
+		},
+		{
+			name:        "explicit plies",
+			color:       "white",
+			moves:       []string{"e2e4", "e7e5", "g1f3", "b8c6"},
+			plies:       2,
+			wantFen:     afterE5,
+			wantUndone:  []string{"g1f3", "b8c6"},
+			wantFromPly: 2,
+		},
+		{name: "black before the bot's first move", color: "black", wantErr: ErrNothingToUndo},
+		{name: "black before their own first move", color: "black", moves: []string{"e2e4"}, wantErr: ErrNothingToUndo},
+		{name: "more plies than played", color: "white", moves: []string{"e2e4", "e7e5"}, plies: 4, wantErr: ErrNothingToUndo},
+		{name: "leaves the bot to move", color: "white", moves: []string{"e2e4", "e7e5"}, plies: 1, wantErr: ErrInvalidUndo},
+		{name: "odd plies from the player's turn", color: "white", moves: []string{"e2e4", "e7e5", "g1f3", "b8c6"}, plies: 3, wantErr: ErrInvalidUndo},
+		{name: "finished game", color: "white", moves: []string{"e2e4", "e7e5"}, finished: true, wantErr: ErrGameOver},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storedGame(t, tt.color, tt.moves...)
+			if tt.finished {
+				store.game.Result, store.game.Termination = models.ResultBlackWins, models.TerminationResignation
+			}
+			service := NewGameplayService(store, nil, nil, nil, nil)
+
+			got, err := service.Undo(store.game.ID, tt.plies)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Undo(%d) error = %v, want %v", tt.plies, err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if store.takeback != nil {
+					t.Errorf("Undo(%d) stored a takeback: %+v", tt.plies, store.takeback)
+				}
+				return
+			}
+
+			if got.Fen != tt.wantFen || got.Turn != tt.color || !reflect.DeepEqual(got.UndoneMoves, tt.wantUndone) {
+				t.Errorf("Undo(%d) = %+v, want fen %s, %s to move, undone %v", tt.plies, got, tt.wantFen, tt.color, tt.wantUndone)
+			}
+			if store.takeback == nil {
+				t.Fatal("Undo() stored no takeback")
+			}
+			if store.takeback.FromMoveOrder != tt.wantFromPly || store.takeback.Plies != len(tt.wantUndone) {
+				t.Errorf("takeback = %+v, want from move %d, %d plies", store.takeback, tt.wantFromPly, len(tt.wantUndone))
+			}
+		})
+	}
+}
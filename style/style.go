@@ -0,0 +1,191 @@
+// Package style lets personalities choose between moves of similar strength
+// by what the moves do rather than by a few centipawns of evaluation.
+package style
+
+import (
+	"math/rand"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/models"
+	"samsungvoicebe/tactics"
+)
+
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   1,
+	chess.Knight: 3,
+	chess.Bishop: 3,
+	chess.Rook:   5,
+	chess.Queen:  9,
+}
+
+var centerSquares = map[chess.Square]bool{
+	chess.D4: true,
+	chess.E4: true,
+	chess.D5: true,
+	chess.E5: true,
+}
+
+// Features is what a move does, as seen by the side playing it.
+type Features struct {
+	// Material is the value in pawns of what the move captures.
+	Material int
+	PawnGrab bool
+	Check    bool
+	// KingZone is how many more squares around the enemy king the mover
+	// attacks after the move than before it.
+	KingZone int
+	Center   bool
+	// Threats counts the enemy pieces left hanging or underdefended, plus one
+	// for a mate threat.
+	Threats int
+	// Loose counts the mover's pieces left attacked and undefended.
+	Loose int
+	Trade bool
+}
+
+// Candidate is a move and its score in centipawns for the side to move.
+type Candidate struct {
+	Move  *chess.Move
+	Score int
+}
+
+// Describe works out the features of move in position.
+func Describe(position *chess.Position, move *chess.Move) Features {
+	board := position.Board()
+	mover := board.Piece(move.S1())
+	after := position.Update(move)
+
+	var features Features
+
+	captured := board.Piece(move.S2())
+	if move.HasTag(chess.EnPassant) {
+		captured = chess.NewPiece(chess.Pawn, mover.Color().Other())
+	}
+	if captured != chess.NoPiece {
+		features.Material = pieceValues[captured.Type()]
+		features.PawnGrab = captured.Type() == chess.Pawn
+		features.Trade = mover.Type() != chess.Pawn && pieceValues[captured.Type()] == pieceValues[mover.Type()]
+	}
+
+	features.Check = move.HasTag(chess.Check)
+	features.Center = centerSquares[move.S2()]
+	features.KingZone = kingZoneAttacks(after.Board(), mover.Color()) - kingZoneAttacks(board, mover.Color())
+
+	for _, threat := range tactics.Threats(after) {
+		switch threat.Kind {
+		case tactics.ThreatHanging, tactics.ThreatUnderdefended, tactics.ThreatMate:
+			features.Threats++
+		}
+	}
+
+	features.Loose = loosePieces(after.Board(), mover.Color())
+
+	return features
+}
+
+// Bonus is the centipawn bonus weights give a move with these features.
+// Safety is paid per piece kept out of danger, so each loose piece costs it.
+func Bonus(features Features, weights models.StyleWeights) int {
+	bonus := features.Material*weights.Material +
+		features.KingZone*weights.KingAttack +
+		features.Threats*weights.Threats -
+		features.Loose*weights.Safety
+	if features.PawnGrab {
+		bonus += weights.PawnGrabs
+	}
+	if features.Check {
+		bonus += weights.Checks
+	}
+	if features.Center {
+		bonus += weights.Center
+	}
+	if features.Trade {
+		bonus += weights.Trades
+	}
+	return bonus
+}
+
+// Choose picks the move personality would play. Only candidates scoring
+// within the personality's window of reference are considered, and each is
+// worth its style bonus minus the centipawns it gives away against
+// reference; ties are broken at random. It returns nil when no candidate is
+// close enough.
+func Choose(position *chess.Position, candidates []Candidate, reference int, personality models.Personality) *chess.Move {
+	var best []*chess.Move
+	bestValue := 0
+	for _, candidate := range candidates {
+		if abs(candidate.Score-reference) > personality.EvalWindow {
+			continue
+		}
+
+		value := Bonus(Describe(position, candidate.Move), personality.Weights)
+		if loss := reference - candidate.Score; loss > 0 {
+			value -= loss
+		}
+
+		switch {
+		case len(best) == 0 || value > bestValue:
+			best = []*chess.Move{candidate.Move}
+			bestValue = value
+		case value == bestValue:
+			best = append(best, candidate.Move)
+		}
+	}
+
+	if len(best) == 0 {
+		return nil
+	}
+	return best[rand.Intn(len(best))]
+}
+
+// Bias scores moves in position by personality's style, for searches that
+// weigh it against their own evaluation.
+func Bias(position *chess.Position, personality models.Personality) func(move *chess.Move) int {
+	return func(move *chess.Move) int {
+		return Bonus(Describe(position, move), personality.Weights)
+	}
+}
+
+// kingZoneAttacks counts the squares around color's opponent's king, the
+// king's own square included, that color attacks.
+func kingZoneAttacks(board *chess.Board, color chess.Color) int {
+	king := tactics.KingSquare(board, color.Other())
+	if king == chess.NoSquare {
+		return 0
+	}
+
+	count := 0
+	for file := int(king.File()) - 1; file <= int(king.File())+1; file++ {
+		for rank := int(king.Rank()) - 1; rank <= int(king.Rank())+1; rank++ {
+			if file < 0 || file > 7 || rank < 0 || rank > 7 {
+				continue
+			}
+			if tactics.IsAttacked(board, chess.NewSquare(chess.File(file), chess.Rank(rank)), color) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// loosePieces counts color's pieces other than the king that the opponent
+// attacks and color does not defend.
+func loosePieces(board *chess.Board, color chess.Color) int {
+	count := 0
+	for square, piece := range board.SquareMap() {
+		if piece.Color() != color || piece.Type() == chess.King {
+			continue
+		}
+		if tactics.IsAttacked(board, square, color.Other()) && !tactics.IsAttacked(board, square, color) {
+			count++
+		}
+	}
+	return count
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
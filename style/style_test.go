@@ -0,0 +1,163 @@
+package style
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/helper"
+	"samsungvoicebe/models"
+)
+
+func position(t *testing.T, fen string) *chess.Position {
+	t.Helper()
+	fenOption, err := chess.FEN(fen)
+	if err != nil {
+		t.Fatalf("chess.FEN(%q): %v", fen, err)
+	}
+	return chess.NewGame(fenOption).Position()
+}
+
+func personality(t *testing.T, name string) models.Personality {
+	t.Helper()
+	personality, ok := models.GetPersonality(name)
+	if !ok {
+		t.Fatalf("no personality %q", name)
+	}
+	return personality
+}
+
+func TestBonus(t *testing.T) {
+	weights := models.StyleWeights{Material: 5, PawnGrabs: 60, Checks: 40, KingAttack: 12, Center: 10, Threats: 15, Safety: 30, Trades: 20}
+
+	tests := []struct {
+		name     string
+		features Features
+		want     int
+	}{
+		{"quiet move", Features{}, 0},
+		{"pawn capture", Features{Material: 1, PawnGrab: true}, 5 + 60},
+		{"trade", Features{Material: 3, Trade: true}, 15 + 20},
+		{"check near the king", Features{Check: true, KingZone: 2}, 40 + 24},
+		{"central threat", Features{Center: true, Threats: 2}, 10 + 30},
+		{"loose pieces cost safety", Features{Loose: 2}, -60},
+		{"king zone given up", Features{KingZone: -1}, -12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Bonus(tt.features, weights); got != tt.want {
+				t.Errorf("Bonus(%+v) = %d, want %d", tt.features, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChoose(t *testing.T) {
+	const (
+		pawnTake   = "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1"
+		rookCheck  = "4k3/8/8/8/8/8/8/R3K3 w - - 0 1"
+		looseHorse = "4k3/8/3p4/4N3/8/8/8/4K3 w - - 0 1"
+		hangingN   = "4k3/8/8/n7/8/8/8/4K2R w - - 0 1"
+	)
+
+	type scored struct {
+		move  string
+		score int
+	}
+
+	tests := []struct {
+		name        string
+		fen         string
+		reference   int
+		candidates  []scored
+		personality models.Personality
+		want        string
+	}{
+		{
+			name:        "plain engine plays the best score",
+			fen:         helper.StartingFEN,
+			reference:   30,
+			candidates:  []scored{{"d2d4", 10}, {"e2e4", 30}},
+			personality: models.Personality{EvalWindow: 100},
+			want:        "e2e4",
+		},
+		{
+			name:        "candidates outside the window are dropped",
+			fen:         pawnTake,
+			candidates:  []scored{{"e4d5", -100}, {"e4e5", 0}},
+			personality: personality(t, models.PersonalityPawnGrabber),
+			want:        "e4e5",
+		},
+		{
+			name:        "nothing within the window",
+			fen:         pawnTake,
+			candidates:  []scored{{"e4d5", -100}},
+			personality: personality(t, models.PersonalityPawnGrabber),
+		},
+		{
+			name:        "eval loss outweighs the bonus",
+			fen:         pawnTake,
+			candidates:  []scored{{"e4d5", -79}, {"e4e5", 0}},
+			personality: models.Personality{EvalWindow: 80, Weights: models.StyleWeights{PawnGrabs: 60}},
+			want:        "e4e5",
+		},
+		{
+			name:        "pawn grabber takes the pawn",
+			fen:         pawnTake,
+			candidates:  []scored{{"e4d5", -20}, {"e4e5", 0}},
+			personality: personality(t, models.PersonalityPawnGrabber),
+			want:        "e4d5",
+		},
+		{
+			name:        "plain engine keeps the pawn move",
+			fen:         pawnTake,
+			candidates:  []scored{{"e4d5", -20}, {"e4e5", 0}},
+			personality: models.Personality{EvalWindow: 80},
+			want:        "e4e5",
+		},
+		{
+			name:        "attacker gives check",
+			fen:         rookCheck,
+			candidates:  []scored{{"a1a8", -30}, {"a1a2", 0}},
+			personality: personality(t, models.PersonalityAttacker),
+			want:        "a1a8",
+		},
+		{
+			name:        "defender saves the loose knight",
+			fen:         looseHorse,
+			candidates:  []scored{{"e5f3", -20}, {"e1d2", 0}},
+			personality: personality(t, models.PersonalityDefender),
+			want:        "e5f3",
+		},
+		{
+			name:        "trickster threatens the hanging knight",
+			fen:         hangingN,
+			candidates:  []scored{{"h1h5", -20}, {"e1d2", 0}},
+			personality: personality(t, models.PersonalityTrickster),
+			want:        "h1h5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := position(t, tt.fen)
+
+			var candidates []Candidate
+			for _, c := range tt.candidates {
+				move, err := helper.DecodeMove(pos, c.move)
+				if err != nil {
+					t.Fatalf("decoding %s: %v", c.move, err)
+				}
+				candidates = append(candidates, Candidate{Move: move, Score: c.score})
+			}
+
+			got := Choose(pos, candidates, tt.reference, tt.personality)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("Choose() = %s, want no move", got)
+			case tt.want != "" && (got == nil || got.String() != tt.want):
+				t.Errorf("Choose() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
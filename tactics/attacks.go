@@ -0,0 +1,117 @@
+// Package tactics answers questions about a position that notnil/chess does
+// not expose: which pieces attack a square, whether a king is in check and
+// what threatens the side to move.
+package tactics
+
+import "github.com/notnil/chess"
+
+type offset struct {
+	file, rank int
+}
+
+var (
+	knightOffsets = []offset{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingOffsets   = []offset{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	diagonals     = []offset{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+	orthogonals   = []offset{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+)
+
+// Attackers returns the squares of color's pieces that attack square, i.e.
+// could capture a piece standing there. Pins are ignored: a pinned piece
+// still attacks the squares it sees.
+func Attackers(board *chess.Board, square chess.Square, color chess.Color) []chess.Square {
+	var attackers []chess.Square
+
+	// Pawns attack diagonally forward, so a white pawn attacking the square
+	// stands one rank below it.
+	pawnRank := -1
+	if color == chess.Black {
+		pawnRank = 1
+	}
+	for _, file := range []int{-1, 1} {
+		if from, ok := shift(square, offset{file, pawnRank}); ok && board.Piece(from) == chess.NewPiece(chess.Pawn, color) {
+			attackers = append(attackers, from)
+		}
+	}
+
+	for _, o := range knightOffsets {
+		if from, ok := shift(square, o); ok && board.Piece(from) == chess.NewPiece(chess.Knight, color) {
+			attackers = append(attackers, from)
+		}
+	}
+
+	for _, o := range kingOffsets {
+		if from, ok := shift(square, o); ok && board.Piece(from) == chess.NewPiece(chess.King, color) {
+			attackers = append(attackers, from)
+		}
+	}
+
+	attackers = append(attackers, sliders(board, square, color, diagonals, chess.Bishop)...)
+	attackers = append(attackers, sliders(board, square, color, orthogonals, chess.Rook)...)
+
+	return attackers
+}
+
+// IsAttacked reports whether any of color's pieces attacks square.
+func IsAttacked(board *chess.Board, square chess.Square, color chess.Color) bool {
+	return len(Attackers(board, square, color)) > 0
+}
+
+// KingSquare returns where color's king stands, or chess.NoSquare when the
+// board has none.
+func KingSquare(board *chess.Board, color chess.Color) chess.Square {
+	king := chess.NewPiece(chess.King, color)
+	for square, piece := range board.SquareMap() {
+		if piece == king {
+			return square
+		}
+	}
+	return chess.NoSquare
+}
+
+// InCheck reports whether the side to move is in check.
+func InCheck(position *chess.Position) bool {
+	board := position.Board()
+	turn := position.Turn()
+
+	king := KingSquare(board, turn)
+	if king == chess.NoSquare {
+		return false
+	}
+	return IsAttacked(board, king, turn.Other())
+}
+
+// sliders walks each direction from square until it hits a piece and keeps
+// it when it is color's queen or the given slider (bishop on diagonals, rook
+// on files and ranks).
+func sliders(board *chess.Board, square chess.Square, color chess.Color, directions []offset, slider chess.PieceType) []chess.Square {
+	var attackers []chess.Square
+	for _, direction := range directions {
+		from := square
+		for {
+			var ok bool
+			from, ok = shift(from, direction)
+			if !ok {
+				break
+			}
+			piece := board.Piece(from)
+			if piece == chess.NoPiece {
+				continue
+			}
+			if piece.Color() == color && (piece.Type() == slider || piece.Type() == chess.Queen) {
+				attackers = append(attackers, from)
+			}
+			break
+		}
+	}
+	return attackers
+}
+
+func shift(square chess.Square, o offset) (chess.Square, bool) {
+	file := int(square.File()) + o.file
+	rank := int(square.Rank()) + o.rank
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return chess.NoSquare, false
+	}
+	return chess.NewSquare(chess.File(file), chess.Rank(rank)), true
+}
@@ -0,0 +1,108 @@
+package tactics
+
+import "github.com/notnil/chess"
+
+const (
+	ThemeCheckmate   = "checkmate"
+	ThemeCheck       = "check"
+	ThemeCapture     = "capture"
+	ThemeFreePiece   = "wins an undefended piece"
+	ThemePromotion   = "promotion"
+	ThemeCastling    = "castling"
+	ThemeFork        = "fork"
+	ThemeEscape      = "saves a threatened piece"
+	ThemeDefendsMate = "stops a mate threat"
+)
+
+// Themes names the tactical ideas behind move, for explaining why it is good:
+// what it captures, whether it checks or mates, forks, promotes, castles,
+// rescues a piece that was in danger or parries a mate threat.
+func Themes(position *chess.Position, move *chess.Move) []string {
+	board := position.Board()
+	mover := board.Piece(move.S1())
+	opponent := mover.Color().Other()
+	after := position.Update(move)
+
+	var themes []string
+
+	switch {
+	case after.Status() == chess.Checkmate:
+		themes = append(themes, ThemeCheckmate)
+	case move.HasTag(chess.Check):
+		themes = append(themes, ThemeCheck)
+	}
+
+	if captured := board.Piece(move.S2()); captured != chess.NoPiece {
+		themes = append(themes, ThemeCapture)
+		if len(Attackers(board, move.S2(), opponent)) == 0 {
+			themes = append(themes, ThemeFreePiece)
+		}
+	} else if move.HasTag(chess.EnPassant) {
+		themes = append(themes, ThemeCapture)
+	}
+
+	if move.Promo() != chess.NoPieceType {
+		themes = append(themes, ThemePromotion)
+	}
+	if move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle) {
+		themes = append(themes, ThemeCastling)
+	}
+	if forks(after, move.S2()) {
+		themes = append(themes, ThemeFork)
+	}
+
+	for _, threat := range Threats(position) {
+		switch {
+		case threat.Kind == ThreatMate && !hasMateThreat(after, mover.Color()):
+			themes = append(themes, ThemeDefendsMate)
+		case (threat.Kind == ThreatHanging || threat.Kind == ThreatUnderdefended) && threat.Square == move.S1():
+			themes = append(themes, ThemeEscape)
+		}
+	}
+
+	return themes
+}
+
+// forks reports whether the piece that just landed on square attacks two or
+// more enemy pieces that are worth more than it or are undefended.
+func forks(after *chess.Position, square chess.Square) bool {
+	board := after.Board()
+	piece := board.Piece(square)
+	enemy := piece.Color().Other()
+
+	targets := 0
+	for _, target := range occupiedSquares(board, enemy) {
+		attackers := Attackers(board, target, piece.Color())
+		if !containsSquare(attackers, square) {
+			continue
+		}
+		targetType := board.Piece(target).Type()
+		if targetType == chess.King || pieceValues[targetType] > pieceValues[piece.Type()] || len(Attackers(board, target, enemy)) == 0 {
+			targets++
+		}
+	}
+	return targets >= 2
+}
+
+// hasMateThreat reports whether, after color has moved, the opponent can
+// mate at once.
+func hasMateThreat(after *chess.Position, color chess.Color) bool {
+	if after.Turn() != color.Other() {
+		return false
+	}
+	for _, move := range after.ValidMoves() {
+		if after.Update(move).Status() == chess.Checkmate {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSquare(squares []chess.Square, square chess.Square) bool {
+	for _, s := range squares {
+		if s == square {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package tactics
+
+import (
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+type ThreatKind string
+
+const (
+	ThreatCheck         ThreatKind = "check"
+	ThreatHanging       ThreatKind = "hanging"
+	ThreatUnderdefended ThreatKind = "underdefended"
+	ThreatPinned        ThreatKind = "pinned"
+	ThreatMate          ThreatKind = "mate_threat"
+)
+
+// Threat is one danger to the side to move. Square is the piece in danger
+// (the king for checks and mate threats) and Attackers the opponent's pieces
+// behind it; for a pin that is the pinning piece. Move is the opponent's
+// mating move for a mate threat.
+type Threat struct {
+	Kind      ThreatKind
+	Square    chess.Square
+	Piece     chess.Piece
+	Attackers []chess.Square
+	Defenders []chess.Square
+	Move      *chess.Move
+}
+
+// pieceValues rank pieces for deciding whether trading a defended piece
+// loses material; the king cannot be traded.
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   1,
+	chess.Knight: 3,
+	chess.Bishop: 3,
+	chess.Rook:   5,
+	chess.Queen:  9,
+	chess.King:   100,
+}
+
+// Threats lists what the side to move should know about before moving: a
+// check, pieces that are attacked and undefended or attacked by something
+// cheaper or more often than they are defended, pieces pinned to the king
+// and a mate the opponent would play if given a free move.
+func Threats(position *chess.Position) []Threat {
+	board := position.Board()
+	turn := position.Turn()
+	opponent := turn.Other()
+
+	var threats []Threat
+
+	king := KingSquare(board, turn)
+	inCheck := false
+	if king != chess.NoSquare {
+		if checkers := Attackers(board, king, opponent); len(checkers) > 0 {
+			inCheck = true
+			threats = append(threats, Threat{
+				Kind:      ThreatCheck,
+				Square:    king,
+				Piece:     board.Piece(king),
+				Attackers: checkers,
+			})
+		}
+	}
+
+	for _, square := range occupiedSquares(board, turn) {
+		piece := board.Piece(square)
+		if piece.Type() == chess.King {
+			continue
+		}
+
+		attackers := Attackers(board, square, opponent)
+		if len(attackers) == 0 {
+			continue
+		}
+		defenders := Attackers(board, square, turn)
+
+		kind := ThreatKind("")
+		switch {
+		case len(defenders) == 0:
+			kind = ThreatHanging
+		case cheapest(board, attackers) < pieceValues[piece.Type()] || len(attackers) > len(defenders):
+			kind = ThreatUnderdefended
+		default:
+			continue
+		}
+		threats = append(threats, Threat{
+			Kind:      kind,
+			Square:    square,
+			Piece:     piece,
+			Attackers: attackers,
+			Defenders: defenders,
+		})
+	}
+
+	if king != chess.NoSquare {
+		threats = append(threats, pins(board, king, turn)...)
+	}
+
+	// A free move for the opponent makes no sense while in check: whatever
+	// is played next has to answer the check anyway.
+	if !inCheck {
+		if move, ok := mateThreat(position); ok {
+			threats = append(threats, Threat{
+				Kind:      ThreatMate,
+				Square:    king,
+				Piece:     board.Piece(king),
+				Attackers: []chess.Square{move.S1()},
+				Move:      move,
+			})
+		}
+	}
+
+	return threats
+}
+
+// pins finds color's pieces that cannot leave the line between their king
+// and an enemy slider without exposing the king.
+func pins(board *chess.Board, king chess.Square, color chess.Color) []Threat {
+	var threats []Threat
+	for _, direction := range append(append([]offset{}, diagonals...), orthogonals...) {
+		slider := chess.Rook
+		if direction.file != 0 && direction.rank != 0 {
+			slider = chess.Bishop
+		}
+
+		pinned := chess.NoSquare
+		square := king
+		for {
+			var ok bool
+			square, ok = shift(square, direction)
+			if !ok {
+				break
+			}
+			piece := board.Piece(square)
+			if piece == chess.NoPiece {
+				continue
+			}
+			if piece.Color() == color {
+				if pinned != chess.NoSquare {
+					break
+				}
+				pinned = square
+				continue
+			}
+			if pinned != chess.NoSquare && (piece.Type() == slider || piece.Type() == chess.Queen) {
+				threats = append(threats, Threat{
+					Kind:      ThreatPinned,
+					Square:    pinned,
+					Piece:     board.Piece(pinned),
+					Attackers: []chess.Square{square},
+				})
+			}
+			break
+		}
+	}
+	return threats
+}
+
+// mateThreat hands the move to the opponent and looks for a mate in one.
+func mateThreat(position *chess.Position) (*chess.Move, bool) {
+	passed, ok := passTurn(position)
+	if !ok {
+		return nil, false
+	}
+	for _, move := range passed.ValidMoves() {
+		if passed.Update(move).Status() == chess.Checkmate {
+			return move, true
+		}
+	}
+	return nil, false
+}
+
+// passTurn is position with the other side to move and no en passant
+// square, as if the side to move had passed.
+func passTurn(position *chess.Position) (*chess.Position, bool) {
+	fields := strings.Fields(position.String())
+	if len(fields) < 4 {
+		return nil, false
+	}
+	if fields[1] == "w" {
+		fields[1] = "b"
+	} else {
+		fields[1] = "w"
+	}
+	fields[3] = "-"
+
+	fenOption, err := chess.FEN(strings.Join(fields, " "))
+	if err != nil {
+		return nil, false
+	}
+	return chess.NewGame(fenOption).Position(), true
+}
+
+func cheapest(board *chess.Board, squares []chess.Square) int {
+	lowest := pieceValues[chess.King]
+	for _, square := range squares {
+		if value := pieceValues[board.Piece(square).Type()]; value < lowest {
+			lowest = value
+		}
+	}
+	return lowest
+}
+
+// occupiedSquares lists color's pieces from a1 to h8 so threats come out in
+// a stable order.
+func occupiedSquares(board *chess.Board, color chess.Color) []chess.Square {
+	var squares []chess.Square
+	for square := chess.A1; square <= chess.H8; square++ {
+		if piece := board.Piece(square); piece != chess.NoPiece && piece.Color() == color {
+			squares = append(squares, square)
+		}
+	}
+	return squares
+}
@@ -0,0 +1,62 @@
+package voice
+
+import (
+	"fmt"
+
+	"github.com/notnil/chess"
+	"samsungvoicebe/helper"
+	"samsungvoicebe/models"
+)
+
+// Candidates lists moves in the structured, speakable form sent to clients.
+func Candidates(position *chess.Position, moves []*chess.Move, language string) []models.MoveCandidate {
+	candidates := make([]models.MoveCandidate, len(moves))
+	for i, move := range moves {
+		candidates[i] = models.MoveCandidate{
+			Move:   move.String(),
+			San:    chess.AlgebraicNotation{}.Encode(position, move),
+			Spoken: DescribeCandidate(position, move, moves, language),
+		}
+	}
+	return candidates
+}
+
+// NewChoice records the candidates of an ambiguous move so the player's
+// answer can be resolved later.
+func NewChoice(position *chess.Position, moves []*chess.Move, narration models.NarrationOptions) models.PendingChoice {
+	choice := models.PendingChoice{
+		Fen:       position.String(),
+		Moves:     make([]string, len(moves)),
+		Narration: narration,
+	}
+	for i, move := range moves {
+		choice.Moves[i] = move.String()
+	}
+	return choice
+}
+
+// ResolveChoice applies the player's answer to a pending choice and returns
+// the position with the candidates that still match. One match means the
+// move is settled; none or several mean the question has to be asked again.
+func ResolveChoice(choice models.PendingChoice, answer string) (*chess.Position, []*chess.Move, error) {
+	fen, err := chess.FEN(choice.Fen)
+	if err != nil {
+		return nil, nil, fmt.Errorf("voice-ResolveChoice-chess.FEN: %w", err)
+	}
+	position := chess.NewGame(fen).Position()
+
+	candidates := make([]*chess.Move, 0, len(choice.Moves))
+	for _, uci := range choice.Moves {
+		move, err := helper.DecodeMove(position, uci)
+		if err != nil {
+			return nil, nil, fmt.Errorf("voice-ResolveChoice-DecodeMove: %w", err)
+		}
+		candidates = append(candidates, move)
+	}
+
+	matches := ChooseCandidate(position, candidates, answer)
+	if len(matches) == 0 {
+		return position, candidates, nil
+	}
+	return position, matches, nil
+}
@@ -0,0 +1,268 @@
+package voice
+
+import (
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+const (
+	LanguageEnglish    = "en"
+	LanguageIndonesian = "id"
+)
+
+// DetectLanguage guesses whether a transcription is Indonesian or English so
+// follow-up questions can be asked in the player's language.
+func DetectLanguage(transcription string) string {
+	text := nonAlphanumeric.ReplaceAllString(strings.ToLower(transcription), " ")
+	for _, word := range strings.Fields(text) {
+		if indonesianWords[word] {
+			return LanguageIndonesian
+		}
+	}
+	return LanguageEnglish
+}
+
+// PieceName is the spoken name of a piece type in the given language.
+func PieceName(pieceType chess.PieceType, language string) string {
+	names, ok := spokenPieces[language]
+	if !ok {
+		names = spokenPieces[LanguageEnglish]
+	}
+	return names[pieceType]
+}
+
+// ParsePiece reads a piece name in English or Indonesian ("knight", "kuda")
+// or a notation letter ("N", "p").
+func ParsePiece(word string) (chess.PieceType, bool) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if pieceType, ok := pieceWords[word]; ok {
+		return pieceType, true
+	}
+	if word == "p" {
+		return chess.Pawn, true
+	}
+	if len(word) == 1 {
+		pieceType, ok := notationPieces[word[0]]
+		return pieceType, ok
+	}
+	return chess.NoPieceType, false
+}
+
+// ParseSquare reads a square written or spoken as "d4", "d 4", "d four" or
+// "d empat".
+func ParseSquare(text string) (chess.Square, bool) {
+	tokens := tokenize(text)
+	if len(tokens) != 1 || tokens[0].kind != tokenSquare {
+		return chess.NoSquare, false
+	}
+	return tokens[0].square, true
+}
+
+// DescribeCandidate says just enough about move to tell it apart from the
+// other candidates: "knight from b1" when they share a destination, "pawn
+// promoting to queen" when only the promotion differs.
+func DescribeCandidate(position *chess.Position, move *chess.Move, candidates []*chess.Move, language string) string {
+	if move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle) {
+		return castleName(move, language)
+	}
+
+	sameFrom, sameTo := true, true
+	for _, candidate := range candidates {
+		if candidate.S1() != move.S1() {
+			sameFrom = false
+		}
+		if candidate.S2() != move.S2() {
+			sameTo = false
+		}
+	}
+
+	from, to, promoting := "from", "to", "promoting to"
+	if language == LanguageIndonesian {
+		from, to, promoting = "dari", "ke", "promosi jadi"
+	}
+
+	// A lone candidate is described in full: "knight from g1 to f3".
+	single := len(candidates) <= 1
+
+	parts := []string{PieceName(position.Board().Piece(move.S1()).Type(), language)}
+	if !sameFrom || single {
+		parts = append(parts, from, move.S1().String())
+	}
+	if !sameTo || single {
+		parts = append(parts, to, move.S2().String())
+	}
+	if move.Promo() != chess.NoPieceType {
+		parts = append(parts, promoting, PieceName(move.Promo(), language))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Question asks the player to pick one of the candidates, e.g. "Knight from
+// b1 or knight from f3?" or "Kuda dari b1 atau kuda dari f3?".
+func Question(position *chess.Position, candidates []*chess.Move, language string) string {
+	or := "or"
+	if language == LanguageIndonesian {
+		or = "atau"
+	}
+
+	phrases := make([]string, len(candidates))
+	for i, move := range candidates {
+		phrases[i] = DescribeCandidate(position, move, candidates, language)
+	}
+
+	var question string
+	switch len(phrases) {
+	case 0:
+		return ""
+	case 1:
+		question = phrases[0]
+	case 2:
+		question = phrases[0] + " " + or + " " + phrases[1]
+	default:
+		question = strings.Join(phrases[:len(phrases)-1], ", ") + ", " + or + " " + phrases[len(phrases)-1]
+	}
+
+	return strings.ToUpper(question[:1]) + question[1:] + "?"
+}
+
+// ConfirmQuestion asks the player to confirm a move that was not understood
+// with enough confidence: "Did you mean knight from g1 to f3?".
+func ConfirmQuestion(position *chess.Position, move *chess.Move, language string) string {
+	described := DescribeCandidate(position, move, []*chess.Move{move}, language)
+	if language == LanguageIndonesian {
+		return "Maksudnya " + described + "?"
+	}
+	return "Did you mean " + described + "?"
+}
+
+// ParseConfirmation reads a spoken yes or no ("yes", "iya", "no", "bukan").
+// ok is false when the answer is neither.
+func ParseConfirmation(answer string) (confirmed bool, ok bool) {
+	text := nonAlphanumeric.ReplaceAllString(strings.ToLower(answer), " ")
+	words := strings.Fields(text)
+
+	// "no", "tidak benar" and "not right" are refusals even though they
+	// contain a yes word, so refusals are checked first.
+	for _, word := range words {
+		if noWords[word] {
+			return false, true
+		}
+	}
+	for _, word := range words {
+		if yesWords[word] {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// ChooseCandidate narrows candidates using the player's answer to Question:
+// a square ("the one from b1", "yang dari b1", "to d2"), a piece ("the
+// knight"), a promotion piece or an ordinal ("the second one", "yang
+// kedua"). It returns nil when the answer names nothing it can use.
+func ChooseCandidate(position *chess.Position, candidates []*chess.Move, answer string) []*chess.Move {
+	tokens := tokenize(answer)
+	board := position.Board()
+
+	matches := candidates
+	narrowed := false
+	filter := func(keep func(move *chess.Move) bool) {
+		var kept []*chess.Move
+		for _, move := range matches {
+			if keep(move) {
+				kept = append(kept, move)
+			}
+		}
+		matches = kept
+		narrowed = true
+	}
+
+	expect := tokenWord
+	for _, t := range tokens {
+		switch t.kind {
+		case tokenFrom, tokenTo:
+			expect = t.kind
+		case tokenCastle:
+			filter(func(move *chess.Move) bool {
+				return move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle)
+			})
+		case tokenKingSide:
+			filter(func(move *chess.Move) bool { return !move.HasTag(chess.QueenSideCastle) })
+		case tokenQueenSide:
+			filter(func(move *chess.Move) bool { return !move.HasTag(chess.KingSideCastle) })
+		case tokenSquare:
+			square := t.square
+			switch expect {
+			case tokenFrom:
+				filter(func(move *chess.Move) bool { return move.S1() == square })
+			case tokenTo:
+				filter(func(move *chess.Move) bool { return move.S2() == square })
+			default:
+				if hasMove(matches, func(move *chess.Move) bool { return move.S1() == square }) {
+					filter(func(move *chess.Move) bool { return move.S1() == square })
+				} else {
+					filter(func(move *chess.Move) bool { return move.S2() == square })
+				}
+			}
+			expect = tokenWord
+		case tokenPiece:
+			piece := t.piece
+			if hasMove(matches, func(move *chess.Move) bool { return board.Piece(move.S1()).Type() == piece }) &&
+				!allMoves(matches, func(move *chess.Move) bool { return board.Piece(move.S1()).Type() == piece }) {
+				filter(func(move *chess.Move) bool { return board.Piece(move.S1()).Type() == piece })
+			} else if hasMove(matches, func(move *chess.Move) bool { return move.Promo() == piece }) {
+				filter(func(move *chess.Move) bool { return move.Promo() == piece })
+			}
+		case tokenWord:
+			ordinal, ok := ordinalWords[t.word]
+			if !ok {
+				continue
+			}
+			if ordinal < 0 {
+				ordinal = len(matches)
+			}
+			if ordinal >= 1 && ordinal <= len(matches) {
+				matches = []*chess.Move{matches[ordinal-1]}
+				narrowed = true
+			}
+		}
+	}
+
+	if !narrowed {
+		return nil
+	}
+	return matches
+}
+
+func castleName(move *chess.Move, language string) string {
+	if language == LanguageIndonesian {
+		if move.HasTag(chess.KingSideCastle) {
+			return "rokade pendek"
+		}
+		return "rokade panjang"
+	}
+	if move.HasTag(chess.KingSideCastle) {
+		return "castle kingside"
+	}
+	return "castle queenside"
+}
+
+func hasMove(moves []*chess.Move, match func(move *chess.Move) bool) bool {
+	for _, move := range moves {
+		if match(move) {
+			return true
+		}
+	}
+	return false
+}
+
+func allMoves(moves []*chess.Move, match func(move *chess.Move) bool) bool {
+	for _, move := range moves {
+		if !match(move) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package voice
+
+import (
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+const (
+	IntentMove          = "move"
+	IntentSquareQuery   = "square_query"
+	IntentPieceQuery    = "piece_query"
+	IntentAttackerQuery = "attacker_query"
+	IntentLastMove      = "last_move"
+	IntentHint          = "hint"
+	IntentUndo          = "undo"
+	IntentResign        = "resign"
+	IntentDrawOffer     = "draw_offer"
+	IntentRepeat        = "repeat"
+)
+
+// Command is what an utterance asks for. Square and Piece are the target of
+// a query (chess.NoSquare and chess.NoPieceType when not named) and Opponent
+// is set when the player asks about the opponent's pieces ("your queen",
+// "kuda lawan") rather than their own.
+type Command struct {
+	Intent   string
+	Square   chess.Square
+	Piece    chess.PieceType
+	Opponent bool
+}
+
+// Classify tells moves apart from the other things a player may say during a
+// game: questions about the board ("what is on d4?", "apa yang menyerang
+// ratu saya?", "where are my knights?"), asking for the last move, a hint, a
+// takeback, resigning, offering a draw or asking to repeat. Anything else is
+// taken to be a move.
+func Classify(transcription string) Command {
+	command := Command{Intent: IntentMove, Square: chess.NoSquare, Piece: chess.NoPieceType}
+
+	text := " " + strings.Join(strings.Fields(nonAlphanumeric.ReplaceAllString(strings.ToLower(transcription), " ")), " ") + " "
+	tokens := tokenize(transcription)
+
+	var attack, squareQuestion, pieceQuestion, moveWords bool
+	for _, t := range tokens {
+		switch t.kind {
+		case tokenSquare:
+			if command.Square == chess.NoSquare {
+				command.Square = t.square
+			}
+		case tokenPiece:
+			if command.Piece == chess.NoPieceType {
+				command.Piece = t.piece
+			}
+		case tokenFrom, tokenTo, tokenCapture, tokenCastle, tokenPromote:
+			moveWords = true
+		case tokenWord:
+			attack = attack || attackWords[t.word]
+			squareQuestion = squareQuestion || squareQuestionWords[t.word]
+			pieceQuestion = pieceQuestion || pieceQuestionWords[t.word]
+			command.Opponent = command.Opponent || opponentWords[t.word]
+		}
+	}
+
+	for _, group := range intentPhrases {
+		for _, phrase := range group.phrases {
+			// "benteng mundur ke a1" still moves a rook: a phrase only
+			// counts when nothing else in the utterance names a move.
+			if strings.Contains(text, " "+phrase+" ") && !namesMove(strings.Replace(text, " "+phrase+" ", " ", 1)) {
+				command.Intent = group.intent
+				return command
+			}
+		}
+	}
+
+	switch {
+	case attack && (command.Square != chess.NoSquare || command.Piece != chess.NoPieceType):
+		command.Intent = IntentAttackerQuery
+	case moveWords:
+	case pieceQuestion && command.Piece != chess.NoPieceType:
+		command.Intent = IntentPieceQuery
+	case squareQuestion && command.Square != chess.NoSquare:
+		command.Intent = IntentSquareQuery
+	}
+	return command
+}
+
+// namesMove reports whether text has a square, from, to or capture word in it.
+func namesMove(text string) bool {
+	for _, t := range tokenize(text) {
+		switch t.kind {
+		case tokenSquare, tokenFrom, tokenTo, tokenCapture:
+			return true
+		}
+	}
+	return false
+}
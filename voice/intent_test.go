@@ -0,0 +1,42 @@
+package voice
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name          string
+		transcription string
+		want          Command
+	}{
+		{"move", "knight to f3", Command{Intent: IntentMove, Square: chess.F3, Piece: chess.Knight}},
+		{"indonesian retreat is a move", "benteng mundur ke a1", Command{Intent: IntentMove, Square: chess.A1, Piece: chess.Rook}},
+		{"retreat to a square", "gajah mundur c1", Command{Intent: IntentMove, Square: chess.C1, Piece: chess.Bishop}},
+		{"help with a capture is a move", "bantu kuda makan d5", Command{Intent: IntentMove, Square: chess.D5, Piece: chess.Knight}},
+		{"draw word next to a square", "seri e4", Command{Intent: IntentMove, Square: chess.E4, Piece: chess.NoPieceType}},
+
+		{"undo", "undo", Command{Intent: IntentUndo, Square: chess.NoSquare, Piece: chess.NoPieceType}},
+		{"take back", "take back my move", Command{Intent: IntentUndo, Square: chess.NoSquare, Piece: chess.NoPieceType}},
+		{"indonesian undo", "mundur satu langkah", Command{Intent: IntentUndo, Square: chess.NoSquare, Piece: chess.NoPieceType}},
+		{"indonesian hint", "bantu saya", Command{Intent: IntentHint, Square: chess.NoSquare, Piece: chess.NoPieceType}},
+		{"indonesian draw", "seri", Command{Intent: IntentDrawOffer, Square: chess.NoSquare, Piece: chess.NoPieceType}},
+		{"resign", "I resign", Command{Intent: IntentResign, Square: chess.NoSquare, Piece: chess.NoPieceType}},
+		{"last move before repeat", "what was your last move again", Command{Intent: IntentLastMove, Square: chess.NoSquare, Piece: chess.NoPieceType, Opponent: true}},
+		{"repeat", "sekali lagi", Command{Intent: IntentRepeat, Square: chess.NoSquare, Piece: chess.NoPieceType}},
+
+		{"square query", "what is on d4", Command{Intent: IntentSquareQuery, Square: chess.D4, Piece: chess.NoPieceType}},
+		{"piece query", "where are your knights", Command{Intent: IntentPieceQuery, Square: chess.NoSquare, Piece: chess.Knight, Opponent: true}},
+		{"attacker query", "apa yang menyerang ratu saya", Command{Intent: IntentAttackerQuery, Square: chess.NoSquare, Piece: chess.Queen}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.transcription); got != tt.want {
+				t.Errorf("Classify(%q) = %+v, want %+v", tt.transcription, got, tt.want)
+			}
+		})
+	}
+}
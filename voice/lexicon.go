@@ -0,0 +1,242 @@
+package voice
+
+import "github.com/notnil/chess"
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenPiece
+	tokenSquare
+	tokenFile
+	tokenFrom
+	tokenTo
+	tokenCapture
+	tokenCastle
+	tokenKingSide
+	tokenQueenSide
+	tokenPromote
+	tokenFront
+)
+
+// pieceWords are the English and Indonesian names (including the common
+// colloquial ones) a player may use for each piece.
+var pieceWords = map[string]chess.PieceType{
+	"king":  chess.King,
+	"kings": chess.King,
+	"raja":  chess.King,
+
+	"queen":      chess.Queen,
+	"queens":     chess.Queen,
+	"ratu":       chess.Queen,
+	"permaisuri": chess.Queen,
+	"putri":      chess.Queen,
+
+	"bishop":  chess.Bishop,
+	"bishops": chess.Bishop,
+	"gajah":   chess.Bishop,
+	"pendeta": chess.Bishop,
+	"uskup":   chess.Bishop,
+	"mentri":  chess.Bishop,
+	"menteri": chess.Bishop,
+
+	"knight":   chess.Knight,
+	"knights":  chess.Knight,
+	"night":    chess.Knight,
+	"horse":    chess.Knight,
+	"kuda":     chess.Knight,
+	"kesatria": chess.Knight,
+	"ksatria":  chess.Knight,
+	"kavaleri": chess.Knight,
+
+	"rook":    chess.Rook,
+	"rooks":   chess.Rook,
+	"tower":   chess.Rook,
+	"benteng": chess.Rook,
+	"menara":  chess.Rook,
+	"kapal":   chess.Rook,
+
+	"pawn":     chess.Pawn,
+	"pawns":    chess.Pawn,
+	"pion":     chess.Pawn,
+	"bidak":    chess.Pawn,
+	"prajurit": chess.Pawn,
+	"serdadu":  chess.Pawn,
+	"tentara":  chess.Pawn,
+}
+
+var connectiveWords = map[string]tokenKind{
+	"from": tokenFrom,
+	"dari": tokenFrom,
+
+	"to":      tokenTo,
+	"into":    tokenTo,
+	"towards": tokenTo,
+	"ke":      tokenTo,
+	"menuju":  tokenTo,
+
+	"takes":     tokenCapture,
+	"take":      tokenCapture,
+	"captures":  tokenCapture,
+	"capture":   tokenCapture,
+	"x":         tokenCapture,
+	"makan":     tokenCapture,
+	"memakan":   tokenCapture,
+	"ambil":     tokenCapture,
+	"mengambil": tokenCapture,
+	"tangkap":   tokenCapture,
+
+	"castle":   tokenCastle,
+	"castles":  tokenCastle,
+	"castling": tokenCastle,
+	"rokade":   tokenCastle,
+
+	"kingside": tokenKingSide,
+	"short":    tokenKingSide,
+	"pendek":   tokenKingSide,
+
+	"queenside": tokenQueenSide,
+	"long":      tokenQueenSide,
+	"panjang":   tokenQueenSide,
+
+	"promote":   tokenPromote,
+	"promotes":  tokenPromote,
+	"promotion": tokenPromote,
+	"promosi":   tokenPromote,
+	"become":    tokenPromote,
+	"becomes":   tokenPromote,
+	"jadi":      tokenPromote,
+	"menjadi":   tokenPromote,
+	"equals":    tokenPromote,
+
+	"front": tokenFront,
+	"depan": tokenFront,
+}
+
+// rankWords lets "e four" and "e empat" be read as e4.
+var rankWords = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4,
+	"five": 5, "six": 6, "seven": 7, "eight": 8,
+
+	"satu": 1, "dua": 2, "tiga": 3, "empat": 4,
+	"lima": 5, "enam": 6, "tujuh": 7, "delapan": 8,
+}
+
+// notationPieces maps SAN piece letters and UCI promotion letters.
+var notationPieces = map[byte]chess.PieceType{
+	'k': chess.King,
+	'q': chess.Queen,
+	'r': chess.Rook,
+	'b': chess.Bishop,
+	'n': chess.Knight,
+}
+
+// ordinalWords let a player pick "the second one" / "yang kedua" from a
+// list of candidates; -1 is the last one.
+var ordinalWords = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "last": -1,
+
+	"pertama": 1, "kedua": 2, "ketiga": 3, "keempat": 4, "terakhir": -1,
+}
+
+// indonesianWords are words that only appear in Indonesian move requests
+// and decide which language spoken answers are given in.
+var indonesianWords = map[string]bool{
+	"ke": true, "dari": true, "di": true, "yang": true, "dan": true, "atau": true,
+	"pindahkan": true, "pindah": true, "majuin": true, "maju": true, "mau": true,
+	"saya": true, "aku": true, "gw": true, "gue": true, "aja": true, "sisi": true,
+	"raja": true, "ratu": true, "permaisuri": true, "putri": true,
+	"gajah": true, "pendeta": true, "uskup": true, "mentri": true, "menteri": true,
+	"kuda": true, "kesatria": true, "ksatria": true, "kavaleri": true,
+	"benteng": true, "menara": true, "kapal": true,
+	"pion": true, "bidak": true, "prajurit": true, "serdadu": true, "tentara": true,
+	"makan": true, "memakan": true, "ambil": true, "mengambil": true, "tangkap": true,
+	"rokade": true, "pendek": true, "panjang": true, "promosi": true, "jadi": true, "menjadi": true,
+	"apa": true, "mana": true, "dimana": true, "siapa": true, "lawan": true, "musuh": true, "langkah": true,
+	"menyerang": true, "diserang": true, "mengancam": true, "petunjuk": true, "bantuan": true, "saran": true,
+	"urungkan": true, "batalkan": true, "menyerah": true, "nyerah": true, "tawarkan": true, "remis": true,
+	"seri": true, "ulangi": true, "tadi": true, "sekali": true, "lagi": true,
+}
+
+var spokenPieces = map[string]map[chess.PieceType]string{
+	LanguageEnglish: {
+		chess.King:   "king",
+		chess.Queen:  "queen",
+		chess.Rook:   "rook",
+		chess.Bishop: "bishop",
+		chess.Knight: "knight",
+		chess.Pawn:   "pawn",
+	},
+	LanguageIndonesian: {
+		chess.King:   "raja",
+		chess.Queen:  "ratu",
+		chess.Rook:   "benteng",
+		chess.Bishop: "gajah",
+		chess.Knight: "kuda",
+		chess.Pawn:   "pion",
+	},
+}
+
+// yesWords and noWords answer a "did you mean ...?" confirmation.
+var (
+	yesWords = map[string]bool{
+		"yes": true, "yeah": true, "yep": true, "yup": true, "correct": true, "right": true,
+		"confirm": true, "ok": true, "okay": true, "sure": true, "play": true,
+
+		"ya": true, "iya": true, "iye": true, "yoi": true, "betul": true, "benar": true,
+		"bener": true, "oke": true, "lanjut": true, "setuju": true, "jalan": true,
+	}
+
+	noWords = map[string]bool{
+		"no": true, "nope": true, "not": true, "wrong": true, "cancel": true, "reject": true,
+
+		"tidak": true, "nggak": true, "ngga": true, "gak": true, "enggak": true,
+		"engga": true, "bukan": true, "salah": true, "batal": true, "jangan": true,
+	}
+)
+
+// intentPhrases are the words and phrases that mark an utterance as
+// something other than a move, checked in order so "what was your last move
+// again" is a last-move question rather than a request to repeat.
+var intentPhrases = []struct {
+	intent  string
+	phrases []string
+}{
+	{IntentResign, []string{"resign", "give up", "i quit", "menyerah", "nyerah", "saya kalah", "aku kalah"}},
+	{IntentDrawOffer, []string{"draw", "remis", "seri"}},
+	{IntentUndo, []string{"undo", "take back", "takeback", "urungkan", "batalkan langkah", "kembalikan langkah", "tarik langkah", "mundur satu langkah", "mundur selangkah"}},
+	{IntentHint, []string{"hint", "help", "suggest", "suggestion", "what should i", "best move", "petunjuk", "bantuan", "bantu saya", "bantu aku", "saran", "langkah terbaik", "sebaiknya"}},
+	{IntentLastMove, []string{
+		"last move", "previous move", "what did you", "what did they", "what did the", "what did my", "opponent s move",
+		"langkah terakhir", "langkah sebelumnya", "langkah lawan", "jalan apa", "main apa",
+	}},
+	{IntentRepeat, []string{"repeat", "again", "pardon", "ulangi", "ulang", "sekali lagi", "apa tadi"}},
+}
+
+// attackWords ask what attacks a piece or square.
+var attackWords = map[string]bool{
+	"attack": true, "attacks": true, "attacking": true, "attacked": true,
+	"threaten": true, "threatens": true, "threatening": true, "threatened": true,
+	"menyerang": true, "serang": true, "diserang": true, "mengancam": true, "ancam": true, "diancam": true,
+}
+
+// questionWords turn "d4" into "what is on d4?" and "knights" into "where
+// are my knights?".
+var (
+	squareQuestionWords = map[string]bool{
+		"what": true, "whats": true, "which": true, "who": true, "empty": true,
+		"apa": true, "ada": true, "siapa": true, "kosong": true,
+	}
+
+	pieceQuestionWords = map[string]bool{
+		"where": true, "wheres": true,
+		"mana": true, "dimana": true,
+	}
+)
+
+// opponentWords make a query about the opponent's pieces instead of the
+// player's own.
+var opponentWords = map[string]bool{
+	"your": true, "their": true, "his": true, "her": true, "opponent": true, "opponents": true, "enemy": true,
+	"lawan": true, "musuh": true, "kamu": true, "bot": true,
+}
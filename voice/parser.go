@@ -0,0 +1,354 @@
+// Package voice turns spoken move descriptions in English or Indonesian
+// ("kuda ke f3", "pawn e2 to e4", "rokade pendek") into legal moves without
+// calling an LLM.
+package voice
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+var (
+	queenSideCastleNotation = regexp.MustCompile(`\b[o0]-[o0]-[o0]\b`)
+	kingSideCastleNotation  = regexp.MustCompile(`\b[o0]-[o0]\b`)
+	sanPromotion            = regexp.MustCompile(`\b([a-h][1-8])\s*=\s*([qrbn])\b`)
+	nonAlphanumeric         = regexp.MustCompile(`[^a-z0-9]+`)
+
+	squarePattern    = regexp.MustCompile(`^[a-h][1-8]$`)
+	uciPattern       = regexp.MustCompile(`^([a-h][1-8])([a-h][1-8])([qrbn])?$`)
+	sanPattern       = regexp.MustCompile(`^([kqrbn])(x?)([a-h][1-8])$`)
+	pawnCapture      = regexp.MustCompile(`^([a-h])x([a-h][1-8])([qrbn])?$`)
+	squarePromotion  = regexp.MustCompile(`^([a-h][1-8])([qrbn])$`)
+	numberedSquare   = regexp.MustCompile(`^[a-h]$`)
+	rankDigitPattern = regexp.MustCompile(`^[1-8]$`)
+)
+
+type token struct {
+	kind   tokenKind
+	piece  chess.PieceType
+	square chess.Square
+	file   int
+	word   string
+}
+
+// intent is what the transcription says about the move, before it is matched
+// against the position.
+type intent struct {
+	mover      chess.PieceType
+	captured   chess.PieceType
+	promo      chess.PieceType
+	locator    chess.PieceType
+	fromFile   int
+	from       chess.Square
+	to         chess.Square
+	capture    bool
+	castle     bool
+	castleSide tokenKind
+}
+
+// ParseMove returns the legal moves in position that match the spoken
+// description. An empty result means the transcription could not be read as
+// a move; more than one means the description is ambiguous.
+func ParseMove(position *chess.Position, transcription string) []*chess.Move {
+	tokens := tokenize(transcription)
+	in, ok := readIntent(tokens)
+	if !ok {
+		return nil
+	}
+	return in.match(position)
+}
+
+func tokenize(transcription string) []token {
+	text := strings.ToLower(transcription)
+	text = queenSideCastleNotation.ReplaceAllString(text, " castle queenside ")
+	text = kingSideCastleNotation.ReplaceAllString(text, " castle kingside ")
+	// "b8=N" would otherwise lose its piece to the punctuation split below.
+	text = sanPromotion.ReplaceAllString(text, "$1$2")
+	words := strings.Fields(nonAlphanumeric.ReplaceAllString(text, " "))
+
+	var tokens []token
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+
+		// "e 4", "e four" and "e empat" as spoken by speech-to-text.
+		if numberedSquare.MatchString(word) && i+1 < len(words) {
+			if rank, ok := spokenRank(words[i+1]); ok {
+				tokens = append(tokens, squareToken(word+strconv.Itoa(rank)))
+				i++
+				continue
+			}
+		}
+
+		if squarePattern.MatchString(word) {
+			tokens = append(tokens, squareToken(word))
+			continue
+		}
+		if m := uciPattern.FindStringSubmatch(word); m != nil {
+			tokens = append(tokens, token{kind: tokenFrom}, squareToken(m[1]), token{kind: tokenTo}, squareToken(m[2]))
+			if m[3] != "" {
+				tokens = append(tokens, token{kind: tokenPromote}, token{kind: tokenPiece, piece: notationPieces[m[3][0]]})
+			}
+			continue
+		}
+		if m := squarePromotion.FindStringSubmatch(word); m != nil {
+			tokens = append(tokens, squareToken(m[1]), token{kind: tokenPromote}, token{kind: tokenPiece, piece: notationPieces[m[2][0]]})
+			continue
+		}
+		// "bxc4" is read as a bishop capture by sanPattern below, but
+		// "bxa8q" can only be a pawn.
+		if m := pawnCapture.FindStringSubmatch(word); m != nil && (m[1] != "b" || m[3] != "") {
+			tokens = append(tokens, token{kind: tokenPiece, piece: chess.Pawn},
+				token{kind: tokenFile, file: int(m[1][0] - 'a')}, token{kind: tokenCapture}, squareToken(m[2]))
+			if m[3] != "" {
+				tokens = append(tokens, token{kind: tokenPromote}, token{kind: tokenPiece, piece: notationPieces[m[3][0]]})
+			}
+			continue
+		}
+		// A lone piece letter straight after a square, as in "b8 n", is the
+		// promotion piece.
+		if piece, ok := notationPieces[word[0]]; ok && len(word) == 1 && piece != chess.King &&
+			len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenSquare {
+			tokens = append(tokens, token{kind: tokenPromote}, token{kind: tokenPiece, piece: piece})
+			continue
+		}
+		if m := sanPattern.FindStringSubmatch(word); m != nil {
+			tokens = append(tokens, token{kind: tokenPiece, piece: notationPieces[m[1][0]]})
+			if m[2] != "" {
+				tokens = append(tokens, token{kind: tokenCapture})
+			} else {
+				tokens = append(tokens, token{kind: tokenTo})
+			}
+			tokens = append(tokens, squareToken(m[3]))
+			continue
+		}
+
+		if piece, ok := pieceWords[word]; ok {
+			tokens = append(tokens, token{kind: tokenPiece, piece: piece, word: word})
+			continue
+		}
+		if kind, ok := connectiveWords[word]; ok {
+			tokens = append(tokens, token{kind: kind, word: word})
+			continue
+		}
+
+		tokens = append(tokens, token{kind: tokenWord, word: word})
+	}
+
+	return tokens
+}
+
+func spokenRank(word string) (int, bool) {
+	if rankDigitPattern.MatchString(word) {
+		return int(word[0] - '0'), true
+	}
+	rank, ok := rankWords[word]
+	return rank, ok
+}
+
+func squareToken(square string) token {
+	return token{kind: tokenSquare, square: parseSquare(square)}
+}
+
+func parseSquare(square string) chess.Square {
+	file := int(square[0] - 'a')
+	rank := int(square[1] - '1')
+	return chess.Square(rank*8 + file)
+}
+
+func readIntent(tokens []token) (intent, bool) {
+	in := intent{
+		mover:    chess.NoPieceType,
+		captured: chess.NoPieceType,
+		promo:    chess.NoPieceType,
+		locator:  chess.NoPieceType,
+		fromFile: -1,
+		from:     chess.NoSquare,
+		to:       chess.NoSquare,
+	}
+
+	var unassigned []chess.Square
+	expect := tokenWord
+	expectPromo := false
+	expectLocator := false
+	sawDestination := false
+
+	for i, t := range tokens {
+		switch t.kind {
+		case tokenFrom, tokenTo:
+			expect = t.kind
+		case tokenCapture:
+			in.capture = true
+			expect = tokenTo
+		case tokenPromote:
+			expectPromo = true
+		case tokenFront:
+			expectLocator = true
+		case tokenCastle:
+			in.castle = true
+			in.castleSide = castleSide(tokens[i+1:])
+		case tokenFile:
+			in.fromFile = t.file
+		case tokenPiece:
+			switch {
+			case expectLocator:
+				in.locator = t.piece
+				expectLocator = false
+			case expectPromo:
+				in.promo = t.piece
+				expectPromo = false
+			case sawDestination && (in.mover == chess.NoPieceType || in.mover == chess.Pawn):
+				// "b7 to b8 queen", "pawn takes a8 knight".
+				in.promo = t.piece
+			case in.mover == chess.NoPieceType && !in.capture:
+				in.mover = t.piece
+			case in.capture && !sawDestination:
+				in.captured = t.piece
+			}
+		case tokenSquare:
+			switch expect {
+			case tokenFrom:
+				in.from = t.square
+			case tokenTo:
+				in.to = t.square
+				sawDestination = true
+			default:
+				unassigned = append(unassigned, t.square)
+				sawDestination = true
+			}
+			expect = tokenWord
+		}
+	}
+
+	switch {
+	case in.from == chess.NoSquare && in.to == chess.NoSquare && len(unassigned) >= 2:
+		in.from, in.to = unassigned[0], unassigned[len(unassigned)-1]
+	case in.to == chess.NoSquare && len(unassigned) > 0:
+		in.to = unassigned[len(unassigned)-1]
+	case in.from == chess.NoSquare && len(unassigned) > 0:
+		in.from = unassigned[0]
+	}
+
+	// "castle to d1" names the rook, not castling.
+	if in.castle && (in.to != chess.NoSquare || in.from != chess.NoSquare) {
+		in.castle = false
+		if in.mover == chess.NoPieceType {
+			in.mover = chess.Rook
+		}
+	}
+
+	understood := in.castle || in.to != chess.NoSquare || in.from != chess.NoSquare ||
+		(in.capture && in.captured != chess.NoPieceType)
+	return in, understood
+}
+
+// castleSide reads the side from the words after "castle"/"rokade":
+// "kingside", "short", "pendek", "sisi raja" or their queen-side opposites.
+func castleSide(tokens []token) tokenKind {
+	for _, t := range tokens {
+		switch t.kind {
+		case tokenKingSide, tokenQueenSide:
+			return t.kind
+		case tokenPiece:
+			if t.piece == chess.King {
+				return tokenKingSide
+			}
+			if t.piece == chess.Queen {
+				return tokenQueenSide
+			}
+		}
+	}
+	return tokenWord
+}
+
+func (in intent) match(position *chess.Position) []*chess.Move {
+	board := position.Board()
+	turn := position.Turn()
+
+	locatorFile := -1
+	if in.locator != chess.NoPieceType {
+		locatorFile = ownPieceFile(board, in.locator, turn)
+	}
+
+	var matches []*chess.Move
+	for _, move := range position.ValidMoves() {
+		isCastle := move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle)
+		if in.castle {
+			if !isCastle {
+				continue
+			}
+			if in.castleSide == tokenKingSide && !move.HasTag(chess.KingSideCastle) {
+				continue
+			}
+			if in.castleSide == tokenQueenSide && !move.HasTag(chess.QueenSideCastle) {
+				continue
+			}
+			matches = append(matches, move)
+			continue
+		}
+
+		piece := board.Piece(move.S1())
+		if in.mover != chess.NoPieceType && piece.Type() != in.mover {
+			continue
+		}
+		if in.from != chess.NoSquare && move.S1() != in.from {
+			continue
+		}
+		if in.fromFile >= 0 && int(move.S1().File()) != in.fromFile {
+			continue
+		}
+		if locatorFile >= 0 && int(move.S1().File()) != locatorFile {
+			continue
+		}
+		if in.to != chess.NoSquare && move.S2() != in.to {
+			continue
+		}
+
+		isCapture := move.HasTag(chess.Capture) || move.HasTag(chess.EnPassant)
+		if in.capture && !isCapture {
+			continue
+		}
+		if in.captured != chess.NoPieceType && capturedType(board, move) != in.captured {
+			continue
+		}
+
+		// Unspecified promotions default to a queen.
+		promo := in.promo
+		if promo == chess.NoPieceType {
+			promo = chess.Queen
+		}
+		if move.Promo() != chess.NoPieceType && move.Promo() != promo {
+			continue
+		}
+
+		matches = append(matches, move)
+	}
+
+	return matches
+}
+
+// ownPieceFile is the file of the side to move's only piece of the given
+// type, or -1 when there is none or more than one ("the pawn in front of the
+// king").
+func ownPieceFile(board *chess.Board, pieceType chess.PieceType, turn chess.Color) int {
+	file := -1
+	for square, piece := range board.SquareMap() {
+		if piece.Type() != pieceType || piece.Color() != turn {
+			continue
+		}
+		if file >= 0 {
+			return -1
+		}
+		file = int(square.File())
+	}
+	return file
+}
+
+func capturedType(board *chess.Board, move *chess.Move) chess.PieceType {
+	if move.HasTag(chess.EnPassant) {
+		return chess.Pawn
+	}
+	return board.Piece(move.S2()).Type()
+}
@@ -0,0 +1,86 @@
+package voice
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+const (
+	startFen     = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	promotionFen = "1r5k/P7/8/8/8/8/8/4K3 w - - 0 1"
+	castlingFen  = "r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1"
+)
+
+func TestParseMove(t *testing.T) {
+	tests := []struct {
+		name          string
+		fen           string
+		transcription string
+		want          []string
+	}{
+		{"uci", startFen, "e2e4", []string{"e2e4"}},
+		{"san", startFen, "Nf3", []string{"g1f3"}},
+		{"english words", startFen, "knight to f3", []string{"g1f3"}},
+		{"indonesian words", startFen, "kuda ke f3", []string{"g1f3"}},
+		{"spoken square", startFen, "pawn e four", []string{"e2e4"}},
+		{"from and to", startFen, "pawn e2 to e4", []string{"e2e4"}},
+		{"ambiguous", "4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "rook d1", []string{"a1d1", "h1d1"}},
+		{"piece without square", startFen, "knight", nil},
+		{"unreadable", startFen, "hello there", nil},
+
+		{"promotion defaults to queen", promotionFen, "a8", []string{"a7a8q"}},
+		{"uci under-promotion", promotionFen, "a7a8n", []string{"a7a8n"}},
+		{"san under-promotion", promotionFen, "a8=N", []string{"a7a8n"}},
+		{"san under-promotion with check", promotionFen, "a8=R+", []string{"a7a8r"}},
+		{"spaced san promotion", promotionFen, "a8 = b", []string{"a7a8b"}},
+		{"glued promotion", promotionFen, "a8n", []string{"a7a8n"}},
+		{"promotion letter after square", promotionFen, "a8 n", []string{"a7a8n"}},
+		{"spoken promotion", promotionFen, "a7 to a8 knight", []string{"a7a8n"}},
+		{"promote to", promotionFen, "a8 promote to rook", []string{"a7a8r"}},
+		{"capture promotion", promotionFen, "axb8=N", []string{"a7b8n"}},
+		{"capture promotion to queen", promotionFen, "axb8", []string{"a7b8q"}},
+
+		{"castle notation", castlingFen, "O-O", []string{"e1g1"}},
+		{"long castle notation", castlingFen, "0-0-0", []string{"e1c1"}},
+		{"castle words", castlingFen, "rokade pendek", []string{"e1g1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fenOption, err := chess.FEN(tt.fen)
+			if err != nil {
+				t.Fatalf("chess.FEN(%q): %v", tt.fen, err)
+			}
+			position := chess.NewGame(fenOption).Position()
+
+			var got []string
+			for _, move := range ParseMove(position, tt.transcription) {
+				got = append(got, move.String())
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMove(%q) = %v, want %v", tt.transcription, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeSanPromotion(t *testing.T) {
+	tokens := tokenize("b8=N")
+
+	var kinds []tokenKind
+	for _, tok := range tokens {
+		kinds = append(kinds, tok.kind)
+	}
+	want := []tokenKind{tokenSquare, tokenPromote, tokenPiece}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("tokenize(%q) kinds = %v, want %v", "b8=N", kinds, want)
+	}
+	if tokens[2].piece != chess.Knight {
+		t.Errorf("tokenize(%q) promotion piece = %v, want knight", "b8=N", tokens[2].piece)
+	}
+}